@@ -0,0 +1,349 @@
+package mango
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bindingSources are the struct tags used to bind request values to
+// model members, in the order in which they are tried.
+var bindingSources = []string{"route", "query", "header", "cookie"}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// BindingError holds details of request values which could not be
+// converted to the type of the model member they were bound to. The
+// failures are keyed by the name of the value, in the same way that
+// Validate keys its results, and have the code "bind".
+type BindingError map[string][]ValidationFailure
+
+func (e BindingError) Error() string {
+	var keys []string
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var msgs []string
+	for _, k := range keys {
+		for _, f := range e[k] {
+			msgs = append(msgs, k+" "+f.Message)
+		}
+	}
+	return "binding failed: " + strings.Join(msgs, " ")
+}
+
+// hasBody returns true if req has content to be decoded.
+// The Content-Type header is not considered, as some clients send it
+// with every request.
+func hasBody(req *http.Request) bool {
+	// an empty body, including http.NoBody, has a ContentLength of zero
+	return req.Body != nil && req.ContentLength != 0
+}
+
+// bindValues populates the unset members of the struct pointed to by m
+// with values from the request, according to their route, query, header
+// and cookie struct tags. Members which are not tagged, or for which the
+// request has no value, are left unchanged. If m is not a pointer to a
+// struct, bindValues does nothing.
+func (c *Context) bindValues(m interface{}) error {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	failures := make(BindingError)
+	c.bindStruct(rv.Elem(), failures)
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+func (c *Context) bindStruct(rv reflect.Value, failures BindingError) {
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		value := rv.Field(i)
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			c.bindStruct(value, failures)
+			continue
+		}
+		if !isZero(value) {
+			continue
+		}
+		for _, src := range bindingSources {
+			name := field.Tag.Get(src)
+			if name == "" || name == "-" {
+				continue
+			}
+			vals := c.requestValues(src, name)
+			if len(vals) == 0 {
+				continue
+			}
+			if err := setValue(value, vals, field.Tag.Get("layout")); err != nil {
+				failures[name] = append(failures[name], ValidationFailure{
+					Code:    "bind",
+					Message: err.Error(),
+				})
+			}
+			break
+		}
+	}
+}
+
+// requestValues returns the values of the named route parameter, query
+// parameter, header or cookie.
+func (c *Context) requestValues(src, name string) []string {
+	switch src {
+	case "route":
+		if v, ok := c.RouteParams[name]; ok {
+			return []string{v}
+		}
+	case "query":
+		return c.Request.URL.Query()[name]
+	case "header":
+		return c.Request.Header[http.CanonicalHeaderKey(name)]
+	case "cookie":
+		if ck, err := c.Request.Cookie(name); err == nil {
+			return []string{ck.Value}
+		}
+	}
+	return nil
+}
+
+// setValue converts vals to the type of v and sets it. Slices receive
+// every value, all other types receive the first. Pointers are set to
+// a newly allocated value.
+func setValue(v reflect.Value, vals []string, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), vals, layout); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.Kind() == reflect.Slice && !isTextUnmarshaler(v) {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setString(s.Index(i), val, layout); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setString(v, vals[0], layout)
+}
+
+// setString converts s to the type of v and sets it.
+func setString(v reflect.Value, s, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.Type() == timeType && layout != "" {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return fmt.Errorf("must be a time in the format %q.", layout)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if isTextUnmarshaler(v) {
+		tu := v.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := tu.UnmarshalText([]byte(s)); err != nil {
+			if v.Type() == timeType {
+				return fmt.Errorf("must be a time in the format %q.", time.RFC3339)
+			}
+			return fmt.Errorf("must be a valid %s.", v.Type().Name())
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false.")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			max := int64(1)<<uint(v.Type().Bits()-1) - 1
+			return fmt.Errorf("must be an integer in the range %d to %d.", -max-1, max)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			max := ^uint64(0) >> uint(64-v.Type().Bits())
+			return fmt.Errorf("must be an integer in the range 0 to %d.", max)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number.")
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot be bound to type %s.", v.Type())
+	}
+	return nil
+}
+
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && reflect.PtrTo(v.Type()).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// BindFailure is the default response model sent by BindAndValidate
+// when a request cannot be bound to a model, or the model fails
+// validation. Failures are keyed by member name, in the same way
+// as Validate keys its results.
+type BindFailure struct {
+	Message  string                         `json:"message"`
+	Failures map[string][]ValidationFailure `json:"failures,omitempty"`
+}
+
+// MarshalXML implements the xml.Marshaler interface. The failures are
+// encoded as a list of failure elements, sorted by name.
+func (f BindFailure) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	v := struct {
+		Message  string      `xml:"message"`
+		Failures xmlFailures `xml:"failures,omitempty"`
+	}{f.Message, xmlFailures(f.Failures)}
+	start.Name = xml.Name{Local: "bindFailure"}
+	return e.EncodeElement(v, start)
+}
+
+// xmlFailures encodes validation failures as a list of failure
+// elements, sorted by name, as XML cannot represent maps.
+type xmlFailures map[string][]ValidationFailure
+
+// MarshalXML implements the xml.Marshaler interface.
+func (f xmlFailures) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type failure struct {
+		Name    string `xml:"name,attr"`
+		Code    string `xml:"code,attr"`
+		Message string `xml:",chardata"`
+	}
+	var v struct {
+		Failures []failure `xml:"failure"`
+	}
+	var keys []string
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, vf := range f[k] {
+			v.Failures = append(v.Failures, failure{k, vf.Code, vf.Message})
+		}
+	}
+	return e.EncodeElement(v, start)
+}
+
+// BindFailureFunc returns the response model sent by BindAndValidate
+// when binding or validation fails. The status is the response status
+// code, err is the binding error, or nil if the model failed validation,
+// and failures holds details of any values which could not be bound or
+// which failed validation.
+type BindFailureFunc func(c *Context, status int, err error, failures map[string][]ValidationFailure) interface{}
+
+// BindAndValidate binds the request to the model m, and then validates
+// it. It returns true if both succeed. Otherwise, an error response is
+// prepared and false is returned, so handlers can simply return:
+//
+//	m := fruit{}
+//	if !c.BindAndValidate(&m) {
+//		return
+//	}
+//
+// The response status is 415 (Unsupported Media Type) if the request
+// content cannot be decoded, the Router BindFailureStatus if the request
+// cannot be bound to m, or the Router ValidationFailureStatus if m fails
+// validation. The response model is the result of the Router
+// BindFailureModel function if it has been set. Otherwise, it is a
+// Problem if the request Accept header includes a problem media type,
+// or a BindFailure. The model is encoded according to the request
+// Accept header.
+func (c *Context) BindAndValidate(m interface{}) bool {
+	if err := c.Bind(m); err != nil {
+		status := c.bindFailureStatus()
+		if _, ok := err.(UnsupportedMediaTypeError); ok {
+			status = http.StatusUnsupportedMediaType
+		}
+		var failures map[string][]ValidationFailure
+		if be, ok := err.(BindingError); ok {
+			failures = be
+		}
+		c.respondBindFailure(status, err, failures)
+		return false
+	}
+	if failures, ok := c.Validate(m); !ok {
+		c.respondBindFailure(c.validationFailureStatus(), nil, failures)
+		return false
+	}
+	return true
+}
+
+func (c *Context) respondBindFailure(status int, err error, failures map[string][]ValidationFailure) {
+	var model interface{}
+	if c.router != nil && c.router.BindFailureModel != nil {
+		model = c.router.BindFailureModel(c, status, err, failures)
+	} else {
+		msg := "The request model failed validation."
+		if err != nil {
+			msg = "The request could not be bound: " + err.Error()
+		}
+		model = BindFailure{Message: msg, Failures: failures}
+		if problemMediaType(c.Request) != "" {
+			p := NewProblem(status, msg)
+			if umt, ok := err.(UnsupportedMediaTypeError); ok {
+				p = umt.Problem()
+			}
+			if len(failures) > 0 {
+				p.Extensions = map[string]interface{}{"failures": failures}
+			}
+			model = p
+		}
+	}
+	r := c.RespondWith(status)
+	if model != nil {
+		r.WithModel(model)
+	}
+}
+
+func (c *Context) bindFailureStatus() int {
+	if c.router != nil && c.router.BindFailureStatus != 0 {
+		return c.router.BindFailureStatus
+	}
+	return http.StatusBadRequest
+}
+
+func (c *Context) validationFailureStatus() int {
+	if c.router != nil && c.router.ValidationFailureStatus != 0 {
+		return c.router.ValidationFailureStatus
+	}
+	return http.StatusUnprocessableEntity
+}
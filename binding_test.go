@@ -0,0 +1,241 @@
+package mango
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindingModel struct {
+	ID      int64     `json:"id" route:"id"`
+	Name    string    `json:"name" query:"name"`
+	Page    *int32    `query:"page"`
+	Tags    []string  `query:"tag"`
+	Active  bool      `query:"active"`
+	Score   float64   `query:"score"`
+	Since   time.Time `query:"since" layout:"2006-01-02"`
+	Until   time.Time `query:"until"`
+	Tenant  string    `header:"X-Tenant"`
+	Session UUID      `cookie:"session"`
+	Count   uint8     `query:"count"`
+	Skipped string    `query:"-"`
+}
+
+func newBindingContext(url string, body string) *Context {
+	var req *http.Request
+	if body == "" {
+		req, _ = http.NewRequest("GET", url, nil)
+	} else {
+		req, _ = http.NewRequest("POST", url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return &Context{
+		Request:       req,
+		RouteParams:   map[string]string{"id": "34"},
+		encoderEngine: newEncoderEngine(),
+	}
+}
+
+func TestBindPopulatesMembersFromRequestValues(t *testing.T) {
+	c := newBindingContext("/items/34?name=Mango&page=2&tag=a&tag=b&active=true&score=1.5&since=2017-06-21&until=2017-06-22T10:00:00Z&count=7&-=x", "")
+	c.Request.Header.Set("X-Tenant", "acme")
+	c.Request.AddCookie(&http.Cookie{Name: "session", Value: "58d5e212-165b-4ca0-909b-c86b9cee0111"})
+	m := bindingModel{}
+	err := c.Bind(&m)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"ID", m.ID, int64(34)},
+		{"Name", m.Name, "Mango"},
+		{"Page", *m.Page, int32(2)},
+		{"Tags", fmt.Sprint(m.Tags), "[a b]"},
+		{"Active", m.Active, true},
+		{"Score", m.Score, 1.5},
+		{"Since", m.Since.Format("2006-01-02"), "2017-06-21"},
+		{"Until", m.Until.Format(time.RFC3339), "2017-06-22T10:00:00Z"},
+		{"Tenant", m.Tenant, "acme"},
+		{"Session", m.Session.String(), "58d5e212-165b-4ca0-909b-c86b9cee0111"},
+		{"Count", m.Count, uint8(7)},
+		{"Skipped", m.Skipped, ""},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestBindOnlyPopulatesMembersUnsetByBody(t *testing.T) {
+	want := "Papaya-34"
+	c := newBindingContext("/items/34?name=Mango", `{"name":"Papaya"}`)
+	m := bindingModel{}
+	err := c.Bind(&m)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	got := fmt.Sprintf("%s-%d", m.Name, m.ID)
+	if got != want {
+		t.Errorf("Bind() = %q, want %q", got, want)
+	}
+}
+
+func TestBindIgnoresContentTypeWhenNoBody(t *testing.T) {
+	want := "Mango-34"
+	c := newBindingContext("/items/34?name=Mango", "")
+	c.Request.Header.Set("Content-Type", "application/json")
+	m := bindingModel{}
+	err := c.Bind(&m)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	got := fmt.Sprintf("%s-%d", m.Name, m.ID)
+	if got != want {
+		t.Errorf("Bind() = %q, want %q", got, want)
+	}
+}
+
+func TestBindLeavesMembersWithoutRequestValuesUnset(t *testing.T) {
+	c := newBindingContext("/items/34", "")
+	m := bindingModel{}
+	err := c.Bind(&m)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if m.Page != nil {
+		t.Errorf("Page = %v, want nil", *m.Page)
+	}
+	if m.Tags != nil {
+		t.Errorf("Tags = %v, want nil", m.Tags)
+	}
+}
+
+func TestBindReturnsBindingErrorWhenConversionFails(t *testing.T) {
+	want := map[string]string{
+		"page":   "must be an integer in the range -2147483648 to 2147483647.",
+		"active": "must be true or false.",
+		"score":  "must be a number.",
+		"since":  `must be a time in the format "2006-01-02".`,
+		"until":  `must be a time in the format "2006-01-02T15:04:05Z07:00".`,
+		"count":  "must be an integer in the range 0 to 255.",
+	}
+	c := newBindingContext("/items/34?name=Mango&page=two&active=maybe&score=high&since=yesterday&until=today&count=300", "")
+	m := bindingModel{}
+	err := c.Bind(&m)
+	be, ok := err.(BindingError)
+	if !ok {
+		t.Fatalf("Error type = %T, want BindingError", err)
+	}
+	if len(be) != len(want) {
+		t.Errorf("Failure count = %d, want %d", len(be), len(want))
+	}
+	for k, msg := range want {
+		fails := be[k]
+		if len(fails) != 1 {
+			t.Errorf("Failures for %q = %d, want 1", k, len(fails))
+			continue
+		}
+		if fails[0].Code != "bind" || fails[0].Message != msg {
+			t.Errorf("Failure for %q = %v, want {bind %s}", k, fails[0], msg)
+		}
+	}
+	if m.Name != "Mango" {
+		t.Errorf("Name = %q, want %q", m.Name, "Mango")
+	}
+}
+
+func TestBindingErrorMessage(t *testing.T) {
+	want := "binding failed: active must be true or false. page must be a number."
+	err := BindingError{
+		"page":   {{Code: "bind", Message: "must be a number."}},
+		"active": {{Code: "bind", Message: "must be true or false."}},
+	}
+	if got := err.Error(); got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
+
+type bindAndValidateModel struct {
+	Name string `json:"name" validate:"alpha"`
+	Page int    `json:"-" query:"page"`
+}
+
+func serveBindAndValidate(r *Router, url, body, ct, accept string) (*httptest.ResponseRecorder, bool) {
+	var ok bool
+	r.Post("/fruit", func(c *Context) {
+		m := bindAndValidateModel{}
+		ok = c.BindAndValidate(&m)
+		if ok {
+			c.RespondWith("OK")
+		}
+	})
+	req, _ := http.NewRequest("POST", url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", ct)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, ok
+}
+
+func TestBindAndValidateResponses(t *testing.T) {
+	tests := []struct {
+		url    string
+		body   string
+		ct     string
+		accept string
+		ok     bool
+		status int
+		want   string
+	}{
+		{"/fruit?page=2", `{"name":"Mango"}`, "application/json", "", true, 200, "OK"},
+		{"/fruit", `{"name":"Mango59"}`, "application/json", "", false, 422,
+			`{"message":"The request model failed validation.","failures":{"name":[{"code":"alpha","message":"must contain only alpha characters."}]}}`},
+		{"/fruit?page=x", `{"name":"Mango"}`, "application/json", "", false, 400,
+			`{"message":"The request could not be bound: binding failed: page must be an integer in the range`},
+		{"/fruit", `{"name":`, "application/json", "", false, 400,
+			`{"message":"The request could not be bound: unexpected EOF"}`},
+		{"/fruit", `name=Mango`, "text/plain", "", false, 415,
+			`{"message":"The request could not be bound: unsupported media type (Content-Type: text/plain)"}`},
+		{"/fruit", `{"name":"Mango59"}`, "application/json", "application/xml", false, 422,
+			`<bindFailure><message>The request model failed validation.</message><failures><failure name="name" code="alpha">must contain only alpha characters.</failure></failures></bindFailure>`},
+	}
+	for _, test := range tests {
+		w, ok := serveBindAndValidate(NewRouter(), test.url, test.body, test.ct, test.accept)
+		if ok != test.ok {
+			t.Errorf("%s: BindAndValidate = %t, want %t", test.body, ok, test.ok)
+		}
+		if w.Code != test.status {
+			t.Errorf("%s: Status = %d, want %d", test.body, w.Code, test.status)
+		}
+		if got := strings.TrimSpace(w.Body.String()); !strings.HasPrefix(got, test.want) {
+			t.Errorf("%s: Body = %q, want %q", test.body, got, test.want)
+		}
+	}
+}
+
+func TestBindAndValidateUsesRouterConfiguration(t *testing.T) {
+	want := `{"count":1,"error":"invalid","status":400}`
+	r := NewRouter()
+	r.ValidationFailureStatus = 400
+	r.BindFailureModel = func(c *Context, status int, err error, failures map[string][]ValidationFailure) interface{} {
+		return map[string]interface{}{"error": "invalid", "status": status, "count": len(failures)}
+	}
+	w, _ := serveBindAndValidate(r, "/fruit", `{"name":"Mango59"}`, "application/json", "")
+	if w.Code != 400 {
+		t.Errorf("Status = %d, want %d", w.Code, 400)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
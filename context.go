@@ -2,11 +2,15 @@ package mango
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Response is an object used to facilitate building a response.
@@ -62,6 +66,22 @@ func (r *Response) WithContentType(ct string) *Response {
 // them if required. It provides many helper methods which are
 // designed to keep your handler code clean and free from boiler
 // code.
+//
+// Context implements the context.Context interface, delegating to the
+// context of the Request, so it can be passed directly to functions
+// which honour cancellation, such as database queries. Hooks can derive
+// a new context with values, or a timeout, which is then seen by the
+// handler:
+//
+//	r.AddPreHook(func(c *mango.Context) {
+//		c.WithTimeout(5 * time.Second)
+//		c.WithValue(tenantKey, tenant(c.Request))
+//	})
+//
+//	func getFruit(c *mango.Context) {
+//		rows, err := db.QueryContext(c, "SELECT ...", c.Value(tenantKey))
+//		...
+//	}
 type Context struct {
 	Request        *http.Request
 	Writer         http.ResponseWriter
@@ -75,6 +95,20 @@ type Context struct {
 	responseReady  bool
 	modelValidator ModelValidator
 	X              interface{}
+	postHooks      []ContextHandlerFunc
+	router         *Router
+	recovered      interface{}
+	stack          []byte
+	// paramConstraints holds the constraints satisfied by RouteParams.
+	paramConstraints map[string]string
+	// err is the error returned by an ErrorHandlerFunc.
+	err error
+	// cancels holds the functions which release the resources of
+	// contexts derived with WithTimeout or WithDeadline.
+	cancels []context.CancelFunc
+	// form is the parsed multipart form of the Request, which is kept
+	// here as the Request can be replaced by SetContext.
+	form *multipart.Form
 }
 
 // ContextHandlerFunc type is an adapter to allow the use of ordinary
@@ -128,6 +162,15 @@ func (c *Context) Authenticated() bool {
 	return c.Identity != nil
 }
 
+// responded returns true if a response has been written directly to the
+// Writer or prepared using one of the response helper methods.
+func (c *Context) responded() bool {
+	if w, ok := c.Writer.(*ResponseWriter); ok && w.responded {
+		return true
+	}
+	return c.responseReady
+}
+
 func (c *Context) urlSchemeHost() string {
 	if c.Request.TLS != nil {
 		return "https://" + c.Request.Host
@@ -135,10 +178,113 @@ func (c *Context) urlSchemeHost() string {
 	return "http://" + c.Request.Host
 }
 
+// URLFor returns the absolute URL of the named route, substituting the
+// pattern parameters with the values in params. The scheme and host are
+// taken from the current request.
+// An error is returned if the route name is unknown, or a parameter
+// value is missing or does not satisfy its constraint.
+func (c *Context) URLFor(name string, params map[string]string) (string, error) {
+	if c.router == nil {
+		return "", fmt.Errorf("unknown route name: %q", name)
+	}
+	p, err := c.router.URL(name, params)
+	if err != nil {
+		return "", err
+	}
+	return c.urlSchemeHost() + p, nil
+}
+
+// Recovered returns the value recovered from a handler panic, and the
+// stack trace at the time of the panic. Both are nil unless called from
+// the Router PanicHandler.
+func (c *Context) Recovered() (interface{}, []byte) {
+	return c.recovered, c.stack
+}
+
+// Context returns the context of the Request. If there is no Request,
+// the background context is returned.
+func (c *Context) Context() context.Context {
+	if c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
+}
+
+// SetContext replaces the context of the Request with ctx, which is
+// usually derived from the existing context. The Request is replaced by
+// a shallow copy, so any references to the previous Request do not see
+// the new context. SetContext panics if ctx is nil.
+func (c *Context) SetContext(ctx context.Context) {
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// WithValue replaces the context of the Request with one in which key
+// is associated with val. The value can be retrieved with the Value
+// method. As with context.WithValue, key should be of a type defined by
+// the caller to avoid collisions.
+func (c *Context) WithValue(key, val interface{}) {
+	c.SetContext(context.WithValue(c.Context(), key, val))
+}
+
+// WithTimeout replaces the context of the Request with one which is
+// cancelled after duration d, or when the request completes, whichever
+// is sooner.
+func (c *Context) WithTimeout(d time.Duration) {
+	c.WithDeadline(time.Now().Add(d))
+}
+
+// WithDeadline replaces the context of the Request with one which is
+// cancelled at time d, or when the request completes, whichever is
+// sooner.
+func (c *Context) WithDeadline(d time.Time) {
+	ctx, cancel := context.WithDeadline(c.Context(), d)
+	c.cancels = append(c.cancels, cancel)
+	c.SetContext(ctx)
+}
+
+// cancel releases the resources of any contexts derived with WithTimeout
+// or WithDeadline. It is called by the Router when the request completes.
+func (c *Context) cancel() {
+	for _, cancel := range c.cancels {
+		cancel()
+	}
+	c.cancels = nil
+}
+
+// Deadline returns the time when the context of the Request will be
+// cancelled, if any. It implements the context.Context interface.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.Context().Deadline()
+}
+
+// Done returns a channel which is closed when the context of the Request
+// is cancelled, e.g. when the client disconnects or a timeout expires.
+// It implements the context.Context interface.
+func (c *Context) Done() <-chan struct{} {
+	return c.Context().Done()
+}
+
+// Err returns the reason the context of the Request was cancelled, or
+// nil if it has not been. It implements the context.Context interface,
+// and is unrelated to errors returned by an ErrorHandlerFunc.
+func (c *Context) Err() error {
+	return c.Context().Err()
+}
+
+// Value returns the value associated with key in the context of the
+// Request, or nil if there is none. It implements the context.Context
+// interface.
+func (c *Context) Value(key interface{}) interface{} {
+	return c.Context().Value(key)
+}
+
 // Error sends the specified message and HTTP status code as a response.
+// If the request Accept header includes application/problem+json or
+// application/problem+xml, the response is a Problem with msg as its
+// Detail, otherwise it is plain text.
 // Request handlers should cease execution after calling this method.
 func (c *Context) Error(msg string, code int) {
-	http.Error(c.Writer, msg, code)
+	httpError(c.Writer, c.Request, msg, code)
 }
 
 // Redirect sends a redirect response using the specified URL and HTTP
@@ -169,6 +315,12 @@ func (c *Context) contentDecoder(r io.Reader) (Decoder, error) {
 		// If the full Content-Type doesn't match try matching only up to the ;
 		decoder, err = c.encoderEngine.GetDecoder(r, strings.Split(ct, ";")[0])
 	}
+	if err != nil {
+		// finally try any structured syntax suffix, e.g. +json
+		if st := suffixMediaType(strings.Split(ct, ";")[0]); st != "" {
+			decoder, err = c.encoderEngine.GetDecoder(r, st)
+		}
+	}
 	if err != nil {
 		return nil,
 			UnsupportedMediaTypeError{
@@ -180,7 +332,12 @@ func (c *Context) contentDecoder(r io.Reader) (Decoder, error) {
 }
 
 func (c *Context) acceptableMediaTypes() []string {
-	hdr := c.Request.Header.Get("Accept")
+	return acceptableMediaTypes(c.Request.Header.Get("Accept"))
+}
+
+// acceptableMediaTypes returns the media types in an Accept header,
+// sorted in order of preference.
+func acceptableMediaTypes(hdr string) []string {
 	hdr = strings.Replace(hdr, " ", "", -1)
 	types := strings.Split(hdr, ",")
 	mt := make(mediaTypes, len(types))
@@ -227,22 +384,59 @@ func (c *Context) GetEncoder() (Encoder, string, error) {
 		if err == nil {
 			return encoder, mt, nil
 		}
+		// fall back on the encoder for any structured syntax suffix,
+		// e.g. application/json for application/vnd.acme.v2+json
+		if st := suffixMediaType(mt); st != "" {
+			encoder, err = c.encoderEngine.GetEncoder(c.Writer, st)
+			if err == nil {
+				return encoder, mt, nil
+			}
+		}
 	}
 	return nil, mt, err
 }
 
 // Bind populates the supplied model with data from the request.
-// This is performed in stages. initially, any requestbody content is
-// deserialized.
-//
-// TODO: Following is not yet implemented:
+// This is performed in stages. Initially, any request body content is
+// deserialized. Then any unset members of a struct model are populated
+// from the route parameters, query parameters, headers and cookies named
+// in their route, query, header and cookie struct tags, e.g.
 //
-// Route parameters are used next to populate any unset members.
-// Finally, query parameters are used to populate any remaining unset members.
+//	type search struct {
+//		Tenant string    `header:"X-Tenant"`
+//		ID     int64     `route:"id"`
+//		Page   *int      `query:"page"`
+//		Tags   []string  `query:"tag"`
+//		Since  time.Time `query:"since" layout:"2006-01-02"`
+//	}
 //
-// This method is under review - currently Binding only uses deserialized
-// request body content.
+// Values are converted to the member type, which can be a string, bool,
+// integer, float, time.Time, any type implementing
+// encoding.TextUnmarshaler, or a pointer to or slice of one of these.
+// Slices receive every value of a query parameter or header. Times are
+// parsed using the layout tag, or as RFC 3339 if there is none.
+// If any values cannot be converted, the remaining members are still
+// populated and a BindingError is returned.
 func (c *Context) Bind(m interface{}) error {
+	if hasBody(c.Request) {
+		if err := c.decodeBody(m); err != nil {
+			return err
+		}
+	} else if c.Request.Header.Get("Content-Type") != "" {
+		// there is nothing to decode, but unsupported content
+		// headers are still reported
+		if _, err := c.contentEncoding(); err != nil {
+			return err
+		}
+		if _, err := c.contentDecoder(strings.NewReader("")); err != nil {
+			return err
+		}
+	}
+	return c.bindValues(m)
+}
+
+// decodeBody deserializes the request body into m.
+func (c *Context) decodeBody(m interface{}) error {
 	r := c.Request.Body
 	ce, err := c.contentEncoding()
 	if err != nil {
@@ -256,18 +450,25 @@ func (c *Context) Bind(m interface{}) error {
 		defer r.Close()
 	}
 
-	decoder, err := c.contentDecoder(r)
-	if err != nil {
-		return err
+	var body io.Reader = r
+	mt, params, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if mt == "multipart/form-data" {
+		if max := c.maxMultipartSize(); max > 0 {
+			body = http.MaxBytesReader(c.Writer, r, max)
+		}
+		body = &multipartBody{
+			Reader:    body,
+			boundary:  params["boundary"],
+			c:         c,
+			maxMemory: c.multipartMemory(),
+		}
 	}
-	err = decoder.Decode(m)
+
+	decoder, err := c.contentDecoder(body)
 	if err != nil {
 		return err
 	}
-
-	// TODO: now update any missing empty properties from url path/query params
-
-	return nil
+	return decoder.Decode(m)
 }
 
 func (c *Context) contentEncoding() (string, error) {
@@ -304,3 +505,8 @@ type UnsupportedMediaTypeError struct {
 func (e UnsupportedMediaTypeError) Error() string {
 	return fmt.Sprintf("unsupported media type (%s: %s)", e.hdr, e.val)
 }
+
+// Problem returns the error as a 415 (Unsupported Media Type) Problem.
+func (e UnsupportedMediaTypeError) Problem() *Problem {
+	return NewProblem(http.StatusUnsupportedMediaType, e.Error())
+}
@@ -3,6 +3,7 @@ package mango
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestContextRespondReturnsResponseStruct(t *testing.T) {
@@ -917,3 +919,104 @@ func TestRespondWithSetsResponseReadyWhenCalledWithArgThatNotIntegerOrString(t *
 		t.Errorf("Model = %t, want %t", got, want)
 	}
 }
+
+type contextTestKey string
+
+func TestContextDelegatesToRequestContext(t *testing.T) {
+	want := "mango"
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.WithValue(context.Background(), contextTestKey("fruit"), want), deadline)
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	c := Context{Request: req.WithContext(ctx)}
+	var _ context.Context = &c
+	if got := c.Value(contextTestKey("fruit")); got != want {
+		t.Errorf("Value = %v, want %v", got, want)
+	}
+	if got, ok := c.Deadline(); !ok || !got.Equal(deadline) {
+		t.Errorf("Deadline = %v, %t, want %v, true", got, ok, deadline)
+	}
+	if err := c.Err(); err != nil {
+		t.Errorf("Err = %q, want nil", err)
+	}
+	cancel()
+	select {
+	case <-c.Done():
+	default:
+		t.Errorf("Done not closed after cancel")
+	}
+	if err := c.Err(); err != context.Canceled {
+		t.Errorf("Err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestContextWithoutRequestUsesBackgroundContext(t *testing.T) {
+	c := Context{}
+	if got := c.Context(); got != context.Background() {
+		t.Errorf("Context = %v, want %v", got, context.Background())
+	}
+	if _, ok := c.Deadline(); ok {
+		t.Errorf("Deadline ok = true, want false")
+	}
+}
+
+func TestContextWithValueReplacesRequestContext(t *testing.T) {
+	want := "mango"
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	c := Context{Request: req}
+	c.WithValue(contextTestKey("fruit"), want)
+	if got := c.Value(contextTestKey("fruit")); got != want {
+		t.Errorf("Value = %v, want %v", got, want)
+	}
+	if got := c.Request.Context().Value(contextTestKey("fruit")); got != want {
+		t.Errorf("Request Value = %v, want %v", got, want)
+	}
+	if got := req.Context().Value(contextTestKey("fruit")); got != nil {
+		t.Errorf("original Request Value = %v, want nil", got)
+	}
+}
+
+func TestPreHookContextIsSeenByHandler(t *testing.T) {
+	want := "mango"
+	var ctx context.Context
+	r := NewRouter()
+	r.AddPreHook(func(c *Context) {
+		c.WithTimeout(time.Minute)
+		c.WithValue(contextTestKey("fruit"), want)
+	})
+	r.Get("/fruit", func(c *Context) {
+		if got := c.Value(contextTestKey("fruit")); got != want {
+			t.Errorf("Value = %v, want %v", got, want)
+		}
+		if _, ok := c.Deadline(); !ok {
+			t.Errorf("Deadline ok = false, want true")
+		}
+		if err := c.Err(); err != nil {
+			t.Errorf("Err = %q, want nil", err)
+		}
+		ctx = c
+	})
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	// the timeout is released when the request completes
+	if ctx == nil {
+		t.Fatalf("handler not called")
+	}
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("Err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestContextWithTimeoutExpires(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	c := Context{Request: req}
+	c.WithTimeout(time.Millisecond)
+	defer c.cancel()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Done not closed after timeout")
+	}
+	if err := c.Err(); err != context.DeadlineExceeded {
+		t.Errorf("Err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -175,7 +175,7 @@ func handleCORS(req *http.Request, w http.ResponseWriter, resource *Resource) (p
 		if !corsConf.methodAllowed(method) {
 			return
 		}
-		if _, ok := resource.Handlers[method]; !ok {
+		if !resource.hasMethod(method) {
 			return
 		}
 
@@ -193,7 +193,7 @@ func handleCORS(req *http.Request, w http.ResponseWriter, resource *Resource) (p
 		// Access-Control-Request-Method, but returning all acceptable methods
 		// for a resource is better for caching
 		for _, m := range corsConf.allMethods() {
-			if _, ok := resource.Handlers[m]; !ok {
+			if !resource.hasMethod(m) {
 				continue
 			}
 			w.Header().Add("Access-Control-Allow-Methods", m)
@@ -124,6 +124,12 @@ func newEncoderEngine() *encoderEngine {
 	e.Decoders["application/xml"] = func(r io.Reader) Decoder {
 		return xml.NewDecoder(r)
 	}
+	e.Decoders["application/x-www-form-urlencoded"] = func(r io.Reader) Decoder {
+		return &formDecoder{r: r}
+	}
+	e.Decoders["multipart/form-data"] = func(r io.Reader) Decoder {
+		return &multipartDecoder{r: r}
+	}
 	e.Encoders["application/json"] = func(w io.Writer) Encoder {
 		return json.NewEncoder(w)
 	}
@@ -0,0 +1,116 @@
+package mango
+
+import (
+	"net/http"
+)
+
+// ErrorHandlerFunc is the signature for route handler functions which
+// return an error. Rather than responding to a failure and remembering
+// to return, the handler simply returns the error, which is mapped to a
+// response by the Router ErrorMapper. ErrorHandlerFuncs are registered
+// using HandleErrors:
+//
+//	r.Get("/fruit/{id:int64}", mango.HandleErrors(getFruit))
+type ErrorHandlerFunc func(*Context) error
+
+// ErrorMapper is the signature for functions which send the response for
+// an error returned by an ErrorHandlerFunc.
+type ErrorMapper func(c *Context, err error)
+
+// HandleErrors returns a ContextHandlerFunc which calls h, and passes
+// any error it returns to the Router ErrorMapper, or DefaultErrorMapper
+// if the Router has none. The error is also recorded in the RequestLog.
+// If h has already responded, the error is recorded but not mapped.
+func HandleErrors(h ErrorHandlerFunc) ContextHandlerFunc {
+	return func(c *Context) {
+		err := h(c)
+		if err == nil {
+			return
+		}
+		if c.responded() {
+			c.err = err
+			return
+		}
+		c.mapError(err)
+	}
+}
+
+// mapError records err and passes it to the Router ErrorMapper, or
+// DefaultErrorMapper if the Router has none.
+func (c *Context) mapError(err error) {
+	c.err = err
+	if c.router != nil && c.router.ErrorMapper != nil {
+		c.router.ErrorMapper(c, err)
+		return
+	}
+	DefaultErrorMapper(c, err)
+}
+
+// DefaultErrorMapper maps errors to responses as follows:
+//
+//	*Problem                   the Problem, with its Status
+//	BindingError               the Router BindFailureStatus, with failures
+//	UnsupportedMediaTypeError  415 (Unsupported Media Type)
+//	ParamError                 404 (Not Found) for route parameters,
+//	                           400 (Bad Request) for query parameters
+//	any other error            500 (Internal Server Error)
+//
+// Binding errors are sent in the same way as by BindAndValidate. Other
+// errors are sent as a Problem if the request Accept header includes a
+// problem media type, or as plain text otherwise. The text of errors
+// mapped to 500 is not sent, to avoid revealing implementation details.
+func DefaultErrorMapper(c *Context, err error) {
+	switch e := err.(type) {
+	case *Problem:
+		c.respondProblem(e)
+		return
+	case BindingError:
+		c.respondBindFailure(c.bindFailureStatus(), err, e)
+		return
+	case UnsupportedMediaTypeError:
+		c.respondBindFailure(http.StatusUnsupportedMediaType, err, nil)
+		return
+	}
+	status := http.StatusInternalServerError
+	msg := http.StatusText(status)
+	if pe, ok := err.(ParamError); ok {
+		status = http.StatusBadRequest
+		if pe.Source == "route" {
+			status = http.StatusNotFound
+		}
+		msg = pe.Error()
+	}
+	if problemMediaType(c.Request) != "" {
+		if status == http.StatusInternalServerError {
+			msg = ""
+		}
+		c.respondProblem(NewProblem(status, msg))
+		return
+	}
+	c.Writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
+	c.RespondWith(status)
+	c.RespondWith(msg + "\n")
+}
+
+// respondProblem prepares p as the response model. Unless the request
+// Accept header specifies otherwise, p is encoded as
+// application/problem+json. If no encoder is acceptable, p is sent
+// immediately in the same way as by the Problem method, rather than
+// losing its status to a 406 (Not Acceptable) response.
+func (c *Context) respondProblem(p *Problem) {
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	if problemMediaType(c.Request) == "" {
+		c.Writer.Header().Set("Content-Type", ProblemJSONMediaType)
+	}
+	if c.encoderEngine != nil {
+		if _, _, err := c.GetEncoder(); err != nil {
+			c.Problem(p)
+			return
+		}
+	}
+	c.RespondWith(status).WithModel(p)
+}
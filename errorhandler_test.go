@@ -0,0 +1,125 @@
+package mango
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleErrorsMapsErrorsToResponses(t *testing.T) {
+	tests := []struct {
+		err    error
+		accept string
+		status int
+		ct     string
+		want   string
+	}{
+		{nil, "", 200, "text/plain; charset=utf-8", "OK"},
+		{errors.New("db password is hunter2"), "", 500, "text/plain; charset=utf-8", "Internal Server Error"},
+		{errors.New("db password is hunter2"), ProblemJSONMediaType, 500, ProblemJSONMediaType,
+			`{"status":500,"title":"Internal Server Error"}`},
+		{NewProblem(409, "already ripe"), "", 409, ProblemJSONMediaType,
+			`{"detail":"already ripe","status":409,"title":"Conflict"}`},
+		{NewProblem(409, "already ripe"), "text/html", 409, ProblemJSONMediaType,
+			`{"detail":"already ripe","status":409,"title":"Conflict"}`},
+		{NewProblem(409, "already ripe"), "application/xml", 409, "application/xml",
+			`<problem xmlns="urn:ietf:rfc:7807"><title>Conflict</title><status>409</status><detail>already ripe</detail></problem>`},
+		{ParamError{"route", "id", "x", "int64", ErrMissingParam}, "", 404, "text/plain; charset=utf-8",
+			`route parameter "id": value missing`},
+		{ParamError{"query", "page", "x", "int", errors.New("invalid syntax")}, "", 400, "text/plain; charset=utf-8",
+			`query parameter "page": cannot convert "x" to int: invalid syntax`},
+		{BindingError{"page": {{Code: "bind", Message: "must be a number."}}}, "", 400, "application/json",
+			`{"message":"The request could not be bound: binding failed: page must be a number.","failures":{"page":[{"code":"bind","message":"must be a number."}]}}`},
+		{UnsupportedMediaTypeError{"Content-Type", "text/csv"}, ProblemJSONMediaType, 415, ProblemJSONMediaType,
+			`{"detail":"unsupported media type (Content-Type: text/csv)","status":415,"title":"Unsupported Media Type"}`},
+	}
+	for _, test := range tests {
+		r := NewRouter()
+		err := test.err
+		r.Get("/fruit", HandleErrors(func(c *Context) error {
+			if err != nil {
+				return err
+			}
+			c.RespondWith("OK")
+			return nil
+		}))
+		req, _ := http.NewRequest("GET", "/fruit", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%v: Status = %d, want %d", test.err, w.Code, test.status)
+		}
+		if got := w.Header().Get("Content-Type"); got != test.ct {
+			t.Errorf("%v: Content-Type = %q, want %q", test.err, got, test.ct)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != test.want {
+			t.Errorf("%v: Body = %q, want %q", test.err, got, test.want)
+		}
+	}
+}
+
+func TestHandleErrorsUsesRouterErrorMapper(t *testing.T) {
+	want := "mapped: boom"
+	r := NewRouter()
+	r.ErrorMapper = func(c *Context, err error) {
+		c.RespondWith(503)
+		c.RespondWith("mapped: " + err.Error())
+	}
+	r.Get("/fruit", HandleErrors(func(c *Context) error {
+		return errors.New("boom")
+	}))
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 503 {
+		t.Errorf("Status = %d, want %d", w.Code, 503)
+	}
+	if got := w.Body.String(); got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
+
+func TestHandleErrorsDoesNotMapErrorWhenHandlerResponded(t *testing.T) {
+	want := "partial"
+	r := NewRouter()
+	r.Get("/fruit", HandleErrors(func(c *Context) error {
+		c.RespondWith(202)
+		c.RespondWith(want)
+		return errors.New("boom")
+	}))
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != 202 {
+		t.Errorf("Status = %d, want %d", w.Code, 202)
+	}
+	if got := w.Body.String(); got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
+
+func TestHandleErrorsRecordsErrorInRequestLog(t *testing.T) {
+	want := "boom"
+	logged := make(chan *RequestLog, 1)
+	r := NewRouter()
+	r.RequestLogger = func(l *RequestLog) {
+		logged <- l
+	}
+	r.Get("/fruit", HandleErrors(func(c *Context) error {
+		return errors.New(want)
+	}))
+	req, _ := http.NewRequest("GET", "/fruit", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	l := <-logged
+	if l.Err == nil {
+		t.Fatalf("Err = nil, want %q", want)
+	}
+	if got := l.Err.Error(); got != want {
+		t.Errorf("Err = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,227 @@
+package mango
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const (
+	// defaultMultipartMemory is the number of bytes of a multipart form
+	// held in memory, if the Router does not specify otherwise.
+	defaultMultipartMemory = 32 << 20
+	// maxFormSize is the largest url encoded form body which will be
+	// decoded.
+	maxFormSize = 10 << 20
+)
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// formDecoder decodes application/x-www-form-urlencoded content.
+type formDecoder struct {
+	r io.Reader
+}
+
+// Decode populates the members of the struct pointed to by v with the
+// form field values named in their form struct tags, or by the member
+// names if they have none. Members tagged with form:"-" are ignored.
+// Values are converted in the same way as Context.Bind converts query
+// parameters, and conversion failures are returned as a BindingError.
+func (d *formDecoder) Decode(v interface{}) error {
+	b, err := ioutil.ReadAll(io.LimitReader(d.r, maxFormSize+1))
+	if err != nil {
+		return err
+	}
+	if len(b) > maxFormSize {
+		return errors.New("form too large")
+	}
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+	return bindForm(v, values, nil)
+}
+
+// multipartBody is the body of a multipart/form-data request, passed to
+// the decoder with its boundary. The parsed form is retained in the
+// request, so uploaded files remain available through Context.Files.
+type multipartBody struct {
+	io.Reader
+	boundary  string
+	c         *Context
+	maxMemory int64
+}
+
+// multipartDecoder decodes multipart/form-data content.
+type multipartDecoder struct {
+	r io.Reader
+}
+
+// Decode populates the members of the struct pointed to by v with the
+// form field values, in the same way as the url encoded form decoder.
+// Members of type *multipart.FileHeader or []*multipart.FileHeader are
+// populated with the uploaded files. File content exceeding the Router
+// MaxMultipartMemory is spooled to temporary files.
+func (d *multipartDecoder) Decode(v interface{}) error {
+	mb, ok := d.r.(*multipartBody)
+	if !ok {
+		// without the request Content-Type, the boundary must be
+		// taken from the content itself
+		r, boundary, err := sniffBoundary(d.r)
+		if err != nil {
+			return err
+		}
+		mb = &multipartBody{Reader: r, boundary: boundary, maxMemory: defaultMultipartMemory}
+	}
+	var form *multipart.Form
+	if mb.c != nil && mb.c.form != nil {
+		form = mb.c.form
+	} else {
+		if mb.boundary == "" {
+			return http.ErrMissingBoundary
+		}
+		var err error
+		form, err = multipart.NewReader(mb, mb.boundary).ReadForm(mb.maxMemory)
+		if err != nil {
+			return err
+		}
+		if mb.c != nil {
+			mb.c.form = form
+			mb.c.Request.MultipartForm = form
+		}
+	}
+	return bindForm(v, form.Value, form.File)
+}
+
+// sniffBoundary returns the boundary of the multipart content in r,
+// together with a reader for the content, starting at the first boundary.
+func sniffBoundary(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if strings.HasPrefix(line, "--") {
+			boundary := strings.TrimRight(line[2:], "\r\n")
+			return io.MultiReader(strings.NewReader(line), br), boundary, nil
+		}
+		if err != nil {
+			return nil, "", http.ErrMissingBoundary
+		}
+	}
+}
+
+// bindForm populates the members of the struct pointed to by m with
+// form values and files. If m is not a pointer to a struct, bindForm
+// returns an error.
+func bindForm(m interface{}, values url.Values, files map[string][]*multipart.FileHeader) error {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("form content can only be bound to a struct pointer")
+	}
+	failures := make(BindingError)
+	bindFormStruct(rv.Elem(), values, files, failures)
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+func bindFormStruct(rv reflect.Value, values url.Values, files map[string][]*multipart.FileHeader, failures BindingError) {
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		value := rv.Field(i)
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			bindFormStruct(value, values, files, failures)
+			continue
+		}
+		name := field.Tag.Get("form")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch {
+		case value.Type() == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				value.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case value.Kind() == reflect.Slice && value.Type().Elem() == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				value.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+		vals := values[name]
+		if len(vals) == 0 {
+			continue
+		}
+		if err := setValue(value, vals, field.Tag.Get("layout")); err != nil {
+			failures[name] = append(failures[name], ValidationFailure{
+				Code:    "bind",
+				Message: err.Error(),
+			})
+		}
+	}
+}
+
+// Files returns the files uploaded in the named field of a
+// multipart/form-data request. The request is parsed, if Bind has not
+// already done so, subject to the Router MaxMultipartSize and
+// MaxMultipartMemory limits. Temporary files are removed once the
+// response has been sent.
+// If the request is not multipart/form-data, or is too large, an error
+// is returned; if there are no files in the field, the error is
+// http.ErrMissingFile.
+func (c *Context) Files(name string) ([]*multipart.FileHeader, error) {
+	if c.form == nil && c.Request.MultipartForm == nil {
+		if max := c.maxMultipartSize(); max > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		}
+		if err := c.Request.ParseMultipartForm(c.multipartMemory()); err != nil {
+			return nil, err
+		}
+	}
+	if c.form == nil {
+		c.form = c.Request.MultipartForm
+	}
+	fhs := c.form.File[name]
+	if len(fhs) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return fhs, nil
+}
+
+// removeForm removes any temporary files of the parsed multipart form.
+// It is called by the Router once the response has been sent and the
+// PostHooks have been called.
+func (c *Context) removeForm() {
+	if c.form != nil {
+		c.form.RemoveAll()
+	}
+}
+
+// multipartMemory returns the number of bytes of multipart form content
+// to hold in memory.
+func (c *Context) multipartMemory() int64 {
+	if c.router != nil && c.router.MaxMultipartMemory > 0 {
+		return c.router.MaxMultipartMemory
+	}
+	return defaultMultipartMemory
+}
+
+func (c *Context) maxMultipartSize() int64 {
+	if c.router == nil {
+		return 0
+	}
+	return c.router.MaxMultipartSize
+}
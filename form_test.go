@@ -0,0 +1,275 @@
+package mango
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type formModel struct {
+	Name    string                  `form:"name"`
+	Age     int                     `form:"age"`
+	Tags    []string                `form:"tag"`
+	Notes   *string                 `form:"notes"`
+	Email   string                  // untagged members use the member name
+	Ignored string                  `form:"-"`
+	Avatar  *multipart.FileHeader   `form:"avatar"`
+	Photos  []*multipart.FileHeader `form:"photo"`
+}
+
+func newMultipartRequest(fields map[string]string, files map[string][]string) *http.Request {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+	for k, contents := range files {
+		for i, c := range contents {
+			fw, _ := w.CreateFormFile(k, k+string(rune('a'+i))+".txt")
+			fw.Write([]byte(c))
+		}
+	}
+	w.Close()
+	req, _ := http.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestBindUrlEncodedForm(t *testing.T) {
+	body := "name=Mango&age=34&tag=a&tag=b&notes=ripe&Email=m%40example.com&Ignored=x"
+	req, _ := http.NewRequest("POST", "/fruit", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := Context{Request: req, encoderEngine: newEncoderEngine()}
+	m := formModel{}
+	err := c.Bind(&m)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"Name", m.Name, "Mango"},
+		{"Age", m.Age, 34},
+		{"Tags", strings.Join(m.Tags, ","), "a,b"},
+		{"Notes", *m.Notes, "ripe"},
+		{"Email", m.Email, "m@example.com"},
+		{"Ignored", m.Ignored, ""},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestBindUrlEncodedFormReturnsBindingError(t *testing.T) {
+	want := "Mango"
+	req, _ := http.NewRequest("POST", "/fruit", strings.NewReader("name=Mango&age=old"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := Context{Request: req, encoderEngine: newEncoderEngine()}
+	m := formModel{}
+	err := c.Bind(&m)
+	be, ok := err.(BindingError)
+	if !ok {
+		t.Fatalf("Error type = %T, want BindingError", err)
+	}
+	if fails := be["age"]; len(fails) != 1 || fails[0].Code != "bind" {
+		t.Errorf("Failures = %v, want one bind failure for age", be)
+	}
+	if got := m.Name; got != want {
+		t.Errorf("Name = %q, want %q", got, want)
+	}
+}
+
+func TestBindMultipartForm(t *testing.T) {
+	req := newMultipartRequest(
+		map[string]string{"name": "Mango", "age": "34"},
+		map[string][]string{"avatar": {"me"}, "photo": {"one", "two"}},
+	)
+	c := Context{Request: req, encoderEngine: newEncoderEngine()}
+	m := formModel{}
+	err := c.Bind(&m)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if m.Name != "Mango" || m.Age != 34 {
+		t.Errorf("Name, Age = %q, %d, want %q, %d", m.Name, m.Age, "Mango", 34)
+	}
+	if m.Avatar == nil || m.Avatar.Filename != "avatara.txt" {
+		t.Errorf("Avatar = %v, want avatara.txt", m.Avatar)
+	}
+	if len(m.Photos) != 2 {
+		t.Fatalf("Photos count = %d, want 2", len(m.Photos))
+	}
+	f, _ := m.Photos[1].Open()
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	if got := string(b); got != "two" {
+		t.Errorf("Photo content = %q, want %q", got, "two")
+	}
+}
+
+func TestFilesReturnsUploadedFiles(t *testing.T) {
+	req := newMultipartRequest(nil, map[string][]string{"photo": {"one", "two"}})
+	c := Context{Request: req, router: NewRouter()}
+	fhs, err := c.Files("photo")
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	got := ""
+	for _, fh := range fhs {
+		got += fh.Filename + ";"
+	}
+	if want := "photoa.txt;photob.txt;"; got != want {
+		t.Errorf("Files = %q, want %q", got, want)
+	}
+	if _, err := c.Files("avatar"); err != http.ErrMissingFile {
+		t.Errorf("Error = %v, want %v", err, http.ErrMissingFile)
+	}
+}
+
+func TestFilesAvailableAfterBind(t *testing.T) {
+	want := 3
+	req := newMultipartRequest(map[string]string{"name": "Mango"}, map[string][]string{"photo": {"one", "two", "three"}})
+	c := Context{Request: req, encoderEngine: newEncoderEngine(), router: NewRouter()}
+	m := struct {
+		Name string `form:"name"`
+	}{}
+	if err := c.Bind(&m); err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	fhs, err := c.Files("photo")
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if got := len(fhs); got != want {
+		t.Errorf("Files count = %d, want %d", got, want)
+	}
+}
+
+func TestMultipartSizeLimit(t *testing.T) {
+	r := NewRouter()
+	r.MaxMultipartSize = 100
+	var bindErr, filesErr error
+	r.Post("/bind", func(c *Context) {
+		m := formModel{}
+		bindErr = c.Bind(&m)
+	})
+	r.Post("/files", func(c *Context) {
+		_, filesErr = c.Files("photo")
+	})
+	content := strings.Repeat("x", 200)
+	for _, p := range []string{"/bind", "/files"} {
+		req := newMultipartRequest(nil, map[string][]string{"photo": {content}})
+		req.URL.Path = p
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if bindErr == nil {
+		t.Errorf("Bind error = nil, want error")
+	}
+	if filesErr == nil {
+		t.Errorf("Files error = nil, want error")
+	}
+}
+
+func TestMultipartFilesSpooledWhenLargerThanMemoryLimit(t *testing.T) {
+	req := newMultipartRequest(nil, map[string][]string{"photo": {strings.Repeat("x", 1<<20)}})
+	r := NewRouter()
+	r.MaxMultipartMemory = 1024
+	c := Context{Request: req, router: r}
+	fhs, err := c.Files("photo")
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	defer req.MultipartForm.RemoveAll()
+	f, _ := fhs[0].Open()
+	defer f.Close()
+	if _, ok := f.(*os.File); !ok {
+		t.Errorf("File type = %T, want *os.File", f)
+	}
+}
+
+func TestMultipartTempFilesRemovedAfterResponse(t *testing.T) {
+	r := NewRouter()
+	r.MaxMultipartMemory = 1024
+	var files, bound []*multipart.FileHeader
+	r.Post("/files", func(c *Context) {
+		files, _ = c.Files("photo")
+	})
+	r.Post("/bind", func(c *Context) {
+		m := formModel{}
+		c.Bind(&m)
+		bound = m.Photos
+	})
+	for _, p := range []string{"/files", "/bind"} {
+		req := newMultipartRequest(nil, map[string][]string{"photo": {strings.Repeat("x", 1<<20)}})
+		req.URL.Path = p
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, fhs := range [][]*multipart.FileHeader{files, bound} {
+		if len(fhs) != 1 {
+			t.Fatalf("Files count = %d, want 1", len(fhs))
+		}
+		if f, err := fhs[0].Open(); err == nil {
+			f.Close()
+			t.Errorf("Open error = nil, want temporary file removed")
+		}
+	}
+}
+
+func TestMultipartTempFilesRemovedWhenRequestReplaced(t *testing.T) {
+	r := NewRouter()
+	r.MaxMultipartMemory = 1024
+	scoped := func(next ContextHandlerFunc) ContextHandlerFunc {
+		return func(c *Context) {
+			req := c.Request
+			c.WithValue("scope", "upload")
+			next(c)
+			c.Request = req
+		}
+	}
+	var files, bound []*multipart.FileHeader
+	r.Post("/files", func(c *Context) {
+		files, _ = c.Files("photo")
+	}, scoped)
+	r.Post("/bind", func(c *Context) {
+		m := formModel{}
+		c.Bind(&m)
+		bound = m.Photos
+	}, scoped)
+	for _, p := range []string{"/files", "/bind"} {
+		req := newMultipartRequest(nil, map[string][]string{"photo": {strings.Repeat("x", 1<<20)}})
+		req.URL.Path = p
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, fhs := range [][]*multipart.FileHeader{files, bound} {
+		if len(fhs) != 1 {
+			t.Fatalf("Files count = %d, want 1", len(fhs))
+		}
+		if f, err := fhs[0].Open(); err == nil {
+			f.Close()
+			t.Errorf("Open error = nil, want temporary file removed")
+		}
+	}
+}
+
+func TestMultipartDecoderFindsBoundaryInContent(t *testing.T) {
+	want := "Mango"
+	req := newMultipartRequest(map[string]string{"name": "Mango"}, nil)
+	d := newEncoderEngine().Decoders["multipart/form-data"](req.Body)
+	m := formModel{}
+	if err := d.Decode(&m); err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if got := m.Name; got != want {
+		t.Errorf("Name = %q, want %q", got, want)
+	}
+}
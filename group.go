@@ -0,0 +1,218 @@
+package mango
+
+import "strings"
+
+// Group is a collection of routes which share a common pattern prefix.
+// A Group can carry its own PreHooks, PostHooks and CORS configuration,
+// which apply only to the routes registered through the Group (or any
+// of its nested groups).
+// New Group objects should be created using the Router Group method, or
+// the Group method of an existing Group to create a nested group.
+type Group struct {
+	router    *Router
+	parent    *Group
+	prefix    string
+	preHooks  []ContextHandlerFunc
+	postHooks []ContextHandlerFunc
+	cors      *CORSConfig
+	mergeCORS bool
+	selector  *selector
+	version   *VersionedHandler
+}
+
+// Group returns a new Group whose routes will all be prefixed with
+// prefix.
+func (r *Router) Group(prefix string) *Group {
+	return &Group{
+		router: r,
+		prefix: strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+// Group returns a new nested Group whose routes will be prefixed with
+// the prefix of the parent group followed by prefix.
+// Nested groups inherit the hooks and CORS configuration of their
+// parent groups.
+func (g *Group) Group(prefix string) *Group {
+	return &Group{
+		router:   g.router,
+		parent:   g,
+		prefix:   g.prefix + strings.TrimSuffix(prefix, "/"),
+		selector: g.selector,
+		version:  g.version,
+	}
+}
+
+// AddPreHook adds a ContextHandlerFunc that will be called before any
+// handler function in the group is called.
+// Group PreHooks are called after the Router PreHooks, and those of
+// a parent group are called before those of a nested group. As with
+// Router PreHooks, they can respond directly, preventing any handler
+// from executing if required.
+// Note: PreHooks are executed in the order they are added.
+func (g *Group) AddPreHook(hook ContextHandlerFunc) {
+	g.preHooks = append(g.preHooks, hook)
+}
+
+// AddPostHook adds a ContextHandlerFunc that will be called after a
+// handler function in the group has been called.
+// Group PostHooks are called before the Router PostHooks, and those of
+// a nested group are called before those of a parent group. As with
+// Router PostHooks, they cannot alter a response.
+// Note: PostHooks are executed in the order they are added.
+func (g *Group) AddPostHook(hook ContextHandlerFunc) {
+	g.postHooks = append(g.postHooks, hook)
+}
+
+// SetCORS sets the CORS configuration that will be used for routes
+// subsequently registered in the group (or any nested group which has
+// no CORS configuration of its own).
+// These settings override any global settings.
+func (g *Group) SetCORS(config CORSConfig) {
+	g.cors = &config
+	g.mergeCORS = false
+}
+
+// AddCORS sets the CORS configuration that will be used for routes
+// subsequently registered in the group (or any nested group which has
+// no CORS configuration of its own), by merging the supplied config
+// with the Router global CORS configuration.
+// SetGlobalCORS MUST be called on the Router before any routes are
+// registered in the group, otherwise registration will panic.
+func (g *Group) AddCORS(config CORSConfig) {
+	g.cors = &config
+	g.mergeCORS = true
+}
+
+// Get registers a new handlerFunc that will be called when HTTP GET
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// Any middleware supplied is called after the group PreHooks, and before
+// handlerFunc, in the order listed.
+// If a GET handlerFunc already exists for the full pattern, Get panics.
+func (g *Group) Get(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "GET", handlerFunc, mw)
+}
+
+// Post registers a new handlerFunc that will be called when HTTP POST
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// If a POST handlerFunc already exists for the full pattern, Post panics.
+func (g *Group) Post(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "POST", handlerFunc, mw)
+}
+
+// Put registers a new handlerFunc that will be called when HTTP PUT
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// If a PUT handlerFunc already exists for the full pattern, Put panics.
+func (g *Group) Put(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "PUT", handlerFunc, mw)
+}
+
+// Patch registers a new handlerFunc that will be called when HTTP PATCH
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// If a PATCH handlerFunc already exists for the full pattern, Patch panics.
+func (g *Group) Patch(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "PATCH", handlerFunc, mw)
+}
+
+// Delete registers a new handlerFunc that will be called when HTTP DELETE
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// If a DELETE handlerFunc already exists for the full pattern, Delete panics.
+func (g *Group) Delete(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "DELETE", handlerFunc, mw)
+}
+
+// Head registers a new handlerFunc that will be called when HTTP HEAD
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// If a HEAD handlerFunc already exists for the full pattern, Head panics.
+func (g *Group) Head(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "HEAD", handlerFunc, mw)
+}
+
+// Options registers a new handlerFunc that will be called when HTTP OPTIONS
+// requests are made to URLs with paths that match the group prefix
+// followed by pattern.
+// If a OPTIONS handlerFunc already exists for the full pattern, Options panics.
+func (g *Group) Options(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return g.addHandlerFunc(pattern, "OPTIONS", handlerFunc, mw)
+}
+
+func (g *Group) addHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc, mw []Middleware) *Route {
+	return g.addRegistration(pattern, method, registration{handlerFunc: handlerFunc, middleware: mw})
+}
+
+func (g *Group) addRegistration(pattern, method string, reg registration) *Route {
+	pattern = g.prefix + pattern
+	routes := g.routes()
+	reg.hooks = g.hooks
+	if g.version != nil {
+		vh := *g.version
+		vh.Handler = reg.handlerFunc
+		vh.Middleware = reg.middleware
+		vh.hooks = reg.hooks
+		vh.name = reg.name
+		routes.AddVersionedHandlerFunc(pattern, method, vh)
+	} else {
+		routes.AddRegistration(pattern, method, reg)
+	}
+
+	for gr := g; gr != nil; gr = gr.parent {
+		if gr.cors == nil {
+			continue
+		}
+		if gr.mergeCORS {
+			routes.AddCORS(pattern, *gr.cors)
+		} else {
+			routes.SetCORS(pattern, *gr.cors)
+		}
+		break
+	}
+	return &Route{
+		router:   g.router,
+		pattern:  pattern,
+		method:   method,
+		selector: g.selector,
+		version:  g.version,
+	}
+}
+
+// routes returns the routes the group registers with; those of the
+// group host or header selector if it has one, otherwise the Router
+// routes.
+func (g *Group) routes() routes {
+	if g.selector != nil {
+		return g.selector.routes
+	}
+	return g.router.routes
+}
+
+// hooks is the Middleware, wrapped around every route registered through
+// the group (outside any route middleware), which runs the PreHooks of
+// the group (and its parents) before calling handlerFunc. The group
+// PostHooks are queued on the Context, so the Router can run them once
+// the response has been sent.
+// Hooks are resolved when the request is handled, so hooks added to a
+// group after its routes have been registered will still be called.
+func (g *Group) hooks(handlerFunc ContextHandlerFunc) ContextHandlerFunc {
+	return func(c *Context) {
+		var chain []*Group
+		for gr := g; gr != nil; gr = gr.parent {
+			chain = append(chain, gr)
+			c.postHooks = append(c.postHooks, gr.postHooks...)
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			for _, h := range chain[i].preHooks {
+				h(c)
+				if c.responded() {
+					return
+				}
+			}
+		}
+		handlerFunc(c)
+	}
+}
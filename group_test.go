@@ -0,0 +1,248 @@
+package mango
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroupPrefixesPattern(t *testing.T) {
+	want := true
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/api/v1")
+	g.Get("/test", testFunc)
+	_, got := rtr.routes.GetResource("/api/v1/test")
+	if got != want {
+		t.Errorf("Resource found = %t, want %t", got, want)
+	}
+}
+
+func TestGroupIgnoresTrailingSlashInPrefix(t *testing.T) {
+	want := true
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/api/v1/")
+	g.Post("/test", testFunc)
+	_, got := rtr.routes.GetResource("/api/v1/test")
+	if got != want {
+		t.Errorf("Resource found = %t, want %t", got, want)
+	}
+}
+
+func TestNestedGroupPrefixesPatternWithParentPrefix(t *testing.T) {
+	want := true
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/api").Group("/v1")
+	g.Put("/test", testFunc)
+	_, got := rtr.routes.GetResource("/api/v1/test")
+	if got != want {
+		t.Errorf("Resource found = %t, want %t", got, want)
+	}
+}
+
+func TestGroupRegistrationMethods(t *testing.T) {
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/api")
+	g.Get("/test", testFunc)
+	g.Post("/test", testFunc)
+	g.Put("/test", testFunc)
+	g.Patch("/test", testFunc)
+	g.Delete("/test", testFunc)
+	g.Head("/test", testFunc)
+	g.Options("/test", testFunc)
+	resource, _ := rtr.routes.GetResource("/api/test")
+	for _, m := range []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"} {
+		if _, ok := resource.Handlers[m]; !ok {
+			t.Errorf("%s handler not added", m)
+		}
+	}
+}
+
+func TestGroupPanicsWhenAttemptingDuplicateRoute(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	rtr.Get("/api/test", testFunc)
+	rtr.Group("/api").Get("/test", testFunc)
+}
+
+func TestGroupHooksCalledInOrder(t *testing.T) {
+	want := "rpre1gpre1npre1handlernpost1gpost1rpost1"
+	callStack := ""
+	hook := func(s string) ContextHandlerFunc {
+		return func(c *Context) {
+			callStack += s
+		}
+	}
+
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	rtr.AddPreHook(hook("rpre1"))
+	rtr.AddPostHook(hook("rpost1"))
+	g := rtr.Group("/api")
+	g.AddPreHook(hook("gpre1"))
+	g.AddPostHook(hook("gpost1"))
+	n := g.Group("/v1")
+	n.AddPreHook(hook("npre1"))
+	n.AddPostHook(hook("npost1"))
+	n.Get("/test", func(c *Context) {
+		callStack += "handler"
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/test", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := callStack
+	if got != want {
+		t.Errorf("Call stack got %q, want %q", got, want)
+	}
+}
+
+func TestGroupHooksAddedAfterRegistrationAreCalled(t *testing.T) {
+	want := "prehookhandlerposthook"
+	callStack := ""
+
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/api")
+	g.Get("/test", func(c *Context) {
+		callStack += "handler"
+	})
+	g.AddPreHook(func(c *Context) {
+		callStack += "prehook"
+	})
+	g.AddPostHook(func(c *Context) {
+		callStack += "posthook"
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/test", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := callStack
+	if got != want {
+		t.Errorf("Call stack got %q, want %q", got, want)
+	}
+}
+
+func TestGroupHooksNotCalledForRoutesOutsideGroup(t *testing.T) {
+	want := "handler"
+	callStack := ""
+
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/admin")
+	g.AddPreHook(func(c *Context) {
+		callStack += "prehook"
+	})
+	g.AddPostHook(func(c *Context) {
+		callStack += "posthook"
+	})
+	rtr.Get("/test", func(c *Context) {
+		callStack += "handler"
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := callStack
+	if got != want {
+		t.Errorf("Call stack got %q, want %q", got, want)
+	}
+}
+
+func TestGroupPreHookResponsesPreventMainHandlerRunning(t *testing.T) {
+	rtr := Router{}
+	rtr.routes = newMockRoutes()
+	g := rtr.Group("/admin")
+	g.AddPreHook(func(c *Context) {
+		c.RespondWith("Not for you").WithStatus(401)
+	})
+	g.AddPreHook(func(c *Context) {
+		t.Errorf("Subsequent PreHooks not ignored")
+	})
+	g.Get("/test", func(c *Context) {
+		t.Errorf("Handler not ignored")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/test", nil)
+	rtr.ServeHTTP(w, req)
+
+	want := 401
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+	wantBody := "Not for you"
+	gotBody := w.Body.String()
+	if gotBody != wantBody {
+		t.Errorf("Body got %q, want %q", gotBody, wantBody)
+	}
+}
+
+func TestGroupSetCORSAppliesToGroupRoutes(t *testing.T) {
+	want := "http://somewhere.com"
+	rtr := NewRouter()
+	g := rtr.Group("/api")
+	g.SetCORS(CORSConfig{Origins: []string{want}})
+	g.Get("/test", testFunc)
+	rtr.Get("/other", testFunc)
+
+	resource, _ := rtr.routes.GetResource("/api/test")
+	if resource.CORSConfig == nil {
+		t.Errorf("CORSConfig = nil, want Origins %q", want)
+		return
+	}
+	got := resource.CORSConfig.Origins[0]
+	if got != want {
+		t.Errorf("Origin got %q, want %q", got, want)
+	}
+
+	resource, _ = rtr.routes.GetResource("/other")
+	if resource.CORSConfig != nil {
+		t.Errorf("CORSConfig = %v, want nil", resource.CORSConfig)
+	}
+}
+
+func TestNestedGroupInheritsParentCORS(t *testing.T) {
+	want := "http://somewhere.com"
+	rtr := NewRouter()
+	g := rtr.Group("/api")
+	g.SetCORS(CORSConfig{Origins: []string{want}})
+	g.Group("/v1").Get("/test", testFunc)
+
+	resource, _ := rtr.routes.GetResource("/api/v1/test")
+	if resource.CORSConfig == nil {
+		t.Errorf("CORSConfig = nil, want Origins %q", want)
+		return
+	}
+	got := resource.CORSConfig.Origins[0]
+	if got != want {
+		t.Errorf("Origin got %q, want %q", got, want)
+	}
+}
+
+func TestGroupAddCORSMergesWithGlobalCORS(t *testing.T) {
+	want := "http://elsewhere.com,http://somewhere.com"
+	rtr := NewRouter()
+	rtr.SetGlobalCORS(CORSConfig{Origins: []string{"http://elsewhere.com"}})
+	g := rtr.Group("/api")
+	g.AddCORS(CORSConfig{Origins: []string{"http://somewhere.com"}})
+	g.Get("/test", testFunc)
+
+	resource, _ := rtr.routes.GetResource("/api/test")
+	got := resource.CORSConfig.Origins[0] + "," + resource.CORSConfig.Origins[1]
+	if got != want {
+		t.Errorf("Origins got %q, want %q", got, want)
+	}
+}
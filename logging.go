@@ -89,6 +89,11 @@ type RequestLog struct {
 
 	// Protocol is the HTTP Protocol used for the request.
 	Protocol string
+
+	// Err is the error returned by the request handler, if it was
+	// registered using HandleErrors. Err is nil if the handler
+	// succeeded.
+	Err error
 }
 
 // CommonFormat returns request data as a string in W3C Common Log Format.
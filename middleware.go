@@ -0,0 +1,30 @@
+package mango
+
+// Middleware is the signature for functions which wrap a route handler
+// function, e.g. to perform authorization before calling the handler.
+// Middleware is attached to individual routes when they are registered:
+//
+//	r.Get("/admin", h, RequireRole("admin"), RateLimit(10))
+//
+// Middleware is applied in the order it is listed, so RequireRole in the
+// example above is called first, and can call the next function in the
+// chain, or respond directly to prevent the handler being called.
+type Middleware func(ContextHandlerFunc) ContextHandlerFunc
+
+// chain returns handlerFunc wrapped in the middleware, with the first
+// middleware outermost.
+func chain(handlerFunc ContextHandlerFunc, mw []Middleware) ContextHandlerFunc {
+	h := handlerFunc
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// withHooks wraps handlerFunc in hooks, unless hooks is nil.
+func withHooks(handlerFunc ContextHandlerFunc, hooks Middleware) ContextHandlerFunc {
+	if hooks == nil {
+		return handlerFunc
+	}
+	return hooks(handlerFunc)
+}
@@ -0,0 +1,162 @@
+package mango
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func requireRole(role string) Middleware {
+	return func(next ContextHandlerFunc) ContextHandlerFunc {
+		return func(c *Context) {
+			if c.Request.Header.Get("X-Role") != role {
+				c.RespondWith("Not for you").WithStatus(403)
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+func recordCall(s string, callStack *string) Middleware {
+	return func(next ContextHandlerFunc) ContextHandlerFunc {
+		return func(c *Context) {
+			*callStack += s
+			next(c)
+		}
+	}
+}
+
+func TestMiddlewareCalledInOrderBeforeHandler(t *testing.T) {
+	want := "mw1mw2handler"
+	callStack := ""
+	rtr := NewRouter()
+	rtr.Get("/mango", func(c *Context) {
+		callStack += "handler"
+	}, recordCall("mw1", &callStack), recordCall("mw2", &callStack))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := callStack
+	if got != want {
+		t.Errorf("Call stack got %q, want %q", got, want)
+	}
+}
+
+func TestMiddlewareCanPreventHandlerRunning(t *testing.T) {
+	want := 403
+	rtr := NewRouter()
+	rtr.Get("/admin", func(c *Context) {
+		t.Errorf("Handler not ignored")
+	}, requireRole("admin"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestMiddlewareOnlyAppliesToItsRoute(t *testing.T) {
+	want := 200
+	rtr := NewRouter()
+	rtr.Get("/admin", testFunc, requireRole("admin"))
+	rtr.Post("/admin", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestGroupMiddlewareCalledAfterGroupPreHooks(t *testing.T) {
+	want := "prehookmw1handler"
+	callStack := ""
+	rtr := NewRouter()
+	g := rtr.Group("/api")
+	g.AddPreHook(func(c *Context) {
+		callStack += "prehook"
+	})
+	g.Get("/mango", func(c *Context) {
+		callStack += "handler"
+	}, recordCall("mw1", &callStack))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := callStack
+	if got != want {
+		t.Errorf("Call stack got %q, want %q", got, want)
+	}
+}
+
+func TestRouterRoutesIncludesMiddleware(t *testing.T) {
+	want := "testFunc|requireRole,recordCall"
+	callStack := ""
+	rtr := NewRouter()
+	rtr.Get("/admin", testFunc, requireRole("admin"), recordCall("mw", &callStack))
+
+	ri := rtr.Routes()[0]
+	got := ri.Handler + "|" + strings.Join(ri.Middleware, ",")
+	if got != want {
+		t.Errorf("Route got %q, want %q", got, want)
+	}
+}
+
+func TestRouterRoutesExcludesGroupHooksFromMiddleware(t *testing.T) {
+	want := "testFunc|requireRole"
+	rtr := NewRouter()
+	rtr.Group("/api").Get("/admin", testFunc, requireRole("admin"))
+
+	ri := rtr.Routes()[0]
+	got := ri.Handler + "|" + strings.Join(ri.Middleware, ",")
+	if got != want {
+		t.Errorf("Route got %q, want %q", got, want)
+	}
+}
+
+func TestRouterRoutesExcludesGroupHooksFromVersionMiddleware(t *testing.T) {
+	want := "testFunc|requireRole"
+	rtr := NewRouter()
+	rtr.Group("/api").Version("2", "application/vnd.acme.v2+json").Get("/admin", testFunc, requireRole("admin"))
+
+	ri := rtr.Routes()[0]
+	got := ri.Handler + "|" + strings.Join(ri.Middleware, ",")
+	if got != want {
+		t.Errorf("Route got %q, want %q", got, want)
+	}
+}
+
+func TestTreeStructureExcludesGroupHooks(t *testing.T) {
+	want := "[GET: requireRole > testFunc]"
+	testTree := tree{}
+	g := &Group{}
+	testTree.AddRegistration("/admin", "GET", registration{handlerFunc: testFunc, middleware: []Middleware{requireRole("admin")}, hooks: g.hooks})
+
+	got := testTree.Structure()
+	if !strings.Contains(got, want) {
+		t.Errorf("Structure got %q, want it to contain %q", got, want)
+	}
+}
+
+func TestTreeStructureIncludesMiddleware(t *testing.T) {
+	want := "[GET: requireRole > testFunc]"
+	testTree := tree{}
+	testTree.AddHandlerFunc("/admin", "GET", testFunc, requireRole("admin"))
+
+	got := testTree.Structure()
+	if !strings.Contains(got, want) {
+		t.Errorf("Structure got %q, want it to contain %q", got, want)
+	}
+}
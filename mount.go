@@ -0,0 +1,123 @@
+package mango
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// mountMethods are the methods registered by Mount.
+var mountMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// Handle registers a standard library http.Handler that will be called
+// when HTTP requests with the specified method are made to URLs with
+// paths that match pattern. The request path is passed to the handler
+// unaltered, and route parameter values are not available to it.
+// As with ContextHandlerFuncs, the RequestLogger, panic recovery and
+// CORS handling of the Router all apply.
+// If a handler already exists for the method and pattern, Handle panics.
+func (r *Router) Handle(method, pattern string, h http.Handler, mw ...Middleware) *Route {
+	return r.addRegistration(pattern, method, handlerRegistration(h, h, mw))
+}
+
+// HandleFunc registers a standard library http.HandlerFunc that will be
+// called when HTTP requests with the specified method are made to URLs
+// with paths that match pattern. See Handle for more details.
+// If a handler already exists for the method and pattern, HandleFunc panics.
+func (r *Router) HandleFunc(method, pattern string, h http.HandlerFunc, mw ...Middleware) *Route {
+	return r.Handle(method, pattern, h, mw...)
+}
+
+// Mount registers a standard library http.Handler that will be called
+// for all HTTP requests made to prefix, or any path beneath it. The prefix
+// is removed from the request path before the handler is called, so a
+// handler mounted at "/admin" will see a request for "/admin/users" as
+// "/users". A request for the prefix itself is seen as "/".
+// The prefix can contain parameters for whole segments, e.g.
+// "/tenants/{id}", in which case the matching segments are removed.
+// Mount registers handlers for the GET, POST, PUT, PATCH, DELETE, HEAD
+// and OPTIONS methods, and panics if a handler already exists for any of
+// them.
+func (r *Router) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	reg := handlerRegistration(h, stripSegments(strings.Count(prefix, "/"), h), nil)
+	for _, m := range mountMethods {
+		if prefix != "" {
+			r.addRegistration(prefix, m, reg)
+		}
+		r.addRegistration(prefix+"/{*path}", m, reg)
+	}
+}
+
+// Mount registers a standard library http.Handler that will be called
+// for all HTTP requests made to the group prefix followed by prefix, or
+// any path beneath it. The full prefix is removed from the request path
+// before the handler is called, and the group hooks and CORS configuration
+// apply. See the Router Mount method for more details.
+func (g *Group) Mount(prefix string, h http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	full := g.prefix + prefix
+	reg := handlerRegistration(h, stripSegments(strings.Count(full, "/"), h), nil)
+	for _, m := range mountMethods {
+		if full != "" {
+			g.addRegistration(prefix, m, reg)
+		}
+		g.addRegistration(prefix+"/{*path}", m, reg)
+	}
+}
+
+// handlerRegistration returns the registration of the adapter which calls
+// the http.Handler wrapper, reporting h as the handler rather than the
+// adapter.
+func handlerRegistration(h, wrapper http.Handler, mw []Middleware) registration {
+	return registration{
+		handlerFunc: adaptHandler(wrapper),
+		middleware:  mw,
+		name:        handlerName(h),
+	}
+}
+
+// handlerName returns the name of h: the function name of an
+// http.HandlerFunc, otherwise the name of its type.
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		return extractFnName(f)
+	}
+	t := reflect.TypeOf(h)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.Name()
+}
+
+// adaptHandler returns a ContextHandlerFunc which calls h with the
+// Context Writer and Request.
+func adaptHandler(h http.Handler) ContextHandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// stripSegments returns a handler which removes the first n segments
+// from the request path before calling h. Segments are counted, rather
+// than a prefix matched, so that the mount prefix can contain parameters.
+func stripSegments(n int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p := req.URL.Path
+		for i := 0; i < n && p != ""; i++ {
+			j := strings.IndexByte(p[1:], '/')
+			if j < 0 {
+				p = ""
+				break
+			}
+			p = p[j+1:]
+		}
+		if p == "" {
+			p = "/"
+		}
+		h.ServeHTTP(w, withPath(req, p))
+	})
+}
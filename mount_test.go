@@ -0,0 +1,166 @@
+package mango
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouterHandleRegistersHTTPHandler(t *testing.T) {
+	want := "200|/debug/vars"
+	rtr := NewRouter()
+	rtr.Handle("GET", "/debug/vars", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/debug/vars", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestRouterHandleFuncOnlyMatchesMethod(t *testing.T) {
+	want := 405
+	rtr := NewRouter()
+	rtr.HandleFunc("POST", "/mango", func(w http.ResponseWriter, req *http.Request) {})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestRouterMountStripsPrefix(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/admin", "GET /"},
+		{"GET", "/admin/", "GET /"},
+		{"POST", "/admin/users/42", "POST /users/42"},
+		{"DELETE", "/admin/users", "DELETE /users"},
+	}
+	rtr := NewRouter()
+	rtr.Mount("/admin/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.Method + " " + req.URL.Path))
+	}))
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		rtr.ServeHTTP(w, req)
+
+		got := w.Body.String()
+		if got != test.want {
+			t.Errorf("%s %q got %q, want %q", test.method, test.path, got, test.want)
+		}
+	}
+}
+
+func TestRouterMountStripsPrefixWithParameters(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tenants/42", "/"},
+		{"/tenants/42/", "/"},
+		{"/tenants/42/users/7", "/users/7"},
+	}
+	rtr := NewRouter()
+	rtr.Mount("/tenants/{id}", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}))
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rtr.ServeHTTP(w, req)
+
+		got := w.Body.String()
+		if got != test.want {
+			t.Errorf("GET %q got %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestGroupMountStripsGroupPrefix(t *testing.T) {
+	want := "200|hook|/users/7"
+	rtr := NewRouter()
+	g := rtr.Group("/api")
+	g.AddPreHook(func(c *Context) {
+		c.Writer.Header().Set("X-Hook", "hook")
+	})
+	g.Mount("/admin", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/admin/users/7", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s|%s", w.Code, w.Header().Get("X-Hook"), w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestRouterMountRecoversPanics(t *testing.T) {
+	want := 500
+	rtr := NewRouter()
+	rtr.Mount("/admin", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("what no mangoes!")
+	}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestRouterMountAppliesCORS(t *testing.T) {
+	want := "http://somewhere.com"
+	rtr := NewRouter()
+	rtr.SetGlobalCORS(CORSConfig{Origins: []string{want}})
+	rtr.Mount("/admin", http.NotFoundHandler())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/users", nil)
+	req.Header.Set("Origin", want)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Header().Get("Access-Control-Allow-Origin")
+	if got != want {
+		t.Errorf("Allow Origin got %q, want %q", got, want)
+	}
+}
+
+func serveVars(w http.ResponseWriter, req *http.Request) {}
+
+func TestRouterRoutesReportsMountedHandlerNames(t *testing.T) {
+	want := "GET /admin ServeMux;GET /admin/{*path} ServeMux;GET /debug/vars serveVars;"
+	rtr := NewRouter()
+	rtr.HandleFunc("GET", "/debug/vars", serveVars)
+	rtr.Mount("/admin", http.NewServeMux())
+
+	got := ""
+	for _, ri := range rtr.Routes() {
+		if ri.Method == "GET" {
+			got += ri.Method + " " + ri.Pattern + " " + ri.Handler + ";"
+		}
+	}
+	if got != want {
+		t.Errorf("Routes got %q, want %q", got, want)
+	}
+}
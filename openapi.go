@@ -0,0 +1,416 @@
+package mango
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the OpenAPI Specification which
+// documents generated by the Router OpenAPI method conform to.
+const OpenAPIVersion = "3.1.0"
+
+// openAPIMethods are the HTTP methods which can be described by an
+// OpenAPI document.
+var openAPIMethods = map[string]bool{
+	"GET": true, "PUT": true, "POST": true, "DELETE": true,
+	"OPTIONS": true, "HEAD": true, "PATCH": true, "TRACE": true,
+}
+
+// yamlMediaTypes are the media types which request a YAML document from
+// the handler returned by OpenAPIHandler.
+var yamlMediaTypes = map[string]bool{
+	"application/yaml":   true,
+	"application/x-yaml": true,
+	"text/yaml":          true,
+}
+
+// OpenAPI is an OpenAPI document describing the routes of a Router.
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+// OpenAPIInfo holds the metadata of the API described by an OpenAPI
+// document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIComponents holds the Schemas of the named struct types used by
+// the models of the routes, which are referenced by the operations.
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// OpenAPIOperation describes a route, i.e. a method of a path.
+type OpenAPIOperation struct {
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a route parameter, or a query, header or
+// cookie value bound to the request model.
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes the request model of a route.
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes a response of a route.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the Schema of a request or response model
+// encoded with a particular media type.
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// OpenAPI returns an OpenAPI document describing the registered routes.
+// Each route is described by the method of its path, with the path
+// parameters typed in accordance with their constraints, e.g. a {id:int32}
+// parameter is an integer with the format int32. The request and response
+// models recorded with the Route Models method are described by Schemas
+// derived from their types and validate tags, and the query, header and
+// cookie values bound to the request model are described as parameters.
+//
+// Models are described in the encoder DefaultMediaType, or the media type
+// of versioned routes. Where several routes share a path and method, such
+// as versions or routes restricted to a host, only the first route
+// reported by Routes is described.
+func (r *Router) OpenAPI(info OpenAPIInfo) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	g := newSchemaGenerator(r.ValidationHandler, "#/components/schemas/")
+	for _, ri := range r.Routes() {
+		if !openAPIMethods[ri.Method] {
+			continue
+		}
+		path, params := openAPIPath(g, ri)
+		method := strings.ToLower(ri.Method)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		if doc.Paths[path][method] != nil {
+			continue
+		}
+		doc.Paths[path][method] = r.openAPIOperation(g, ri, params)
+	}
+	if len(g.defs) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: g.defs}
+	}
+	return doc
+}
+
+// openAPIOperation returns the description of route ri, whose path
+// parameters are params.
+func (r *Router) openAPIOperation(g *schemaGenerator, ri RouteInfo, params []*OpenAPIParameter) *OpenAPIOperation {
+	mt := ri.MediaType
+	if mt == "" && r.EncoderEngine != nil {
+		mt = r.EncoderEngine.DefaultMediaType()
+	}
+	if mt == "" {
+		mt = DefaultMediaType
+	}
+	op := &OpenAPIOperation{
+		Parameters: params,
+		Responses: map[string]*OpenAPIResponse{
+			"200": {Description: http.StatusText(http.StatusOK)},
+		},
+	}
+	if ri.Response != nil {
+		op.Responses["200"].Content = map[string]*OpenAPIMediaType{
+			mt: {Schema: g.schema(ri.Response, "", false)},
+		}
+	}
+	if ri.Request == nil {
+		return op
+	}
+
+	op.Parameters = append(op.Parameters, boundParameters(g, ri.Request, op.Parameters)...)
+	if ri.Method != "GET" && ri.Method != "HEAD" && ri.Method != "DELETE" && hasBodyMembers(ri.Request) {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]*OpenAPIMediaType{
+				mt: {Schema: g.schema(ri.Request, "", false)},
+			},
+		}
+	}
+
+	// the responses sent by BindAndValidate
+	var failure *Schema
+	if r.BindFailureModel == nil {
+		failure = g.schema(reflect.TypeOf(BindFailure{}), "", false)
+	}
+	c := &Context{router: r}
+	for _, status := range []int{c.bindFailureStatus(), c.validationFailureStatus()} {
+		resp := &OpenAPIResponse{Description: http.StatusText(status)}
+		if failure != nil {
+			resp.Content = map[string]*OpenAPIMediaType{mt: {Schema: failure}}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	return op
+}
+
+// openAPIPath returns the path template of route ri, which omits the
+// parameter constraints, and the descriptions of its parameters.
+func openAPIPath(g *schemaGenerator, ri RouteInfo) (string, []*OpenAPIParameter) {
+	var params []*OpenAPIParameter
+	pattern := ri.Pattern
+	path := ""
+	for {
+		i := strings.IndexByte(pattern, '{')
+		if i < 0 {
+			break
+		}
+		path += pattern[:i]
+		pattern = pattern[i+1:]
+		i = paramEnd(pattern)
+		name, _, _ := parseParam(pattern[:i])
+		name = strings.TrimPrefix(name, "*")
+		pattern = pattern[i+1:]
+		path += "{" + name + "}"
+		params = append(params, &OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   g.paramSchema(ri.Constraints[name]),
+		})
+	}
+	return path + pattern, params
+}
+
+// boundParameters returns the descriptions of the route parameters and
+// query, header and cookie values bound to the members of request model
+// type t. Route parameters in params which have no constraint take the
+// Schema of the member they are bound to, and are not returned.
+func boundParameters(g *schemaGenerator, t reflect.Type, params []*OpenAPIParameter) []*OpenAPIParameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var bound []*OpenAPIParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			bound = append(bound, boundParameters(g, field.Type, params)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		constraints := field.Tag.Get("validate")
+		for _, src := range bindingSources {
+			name := field.Tag.Get(src)
+			if name == "" || name == "-" {
+				continue
+			}
+			s := g.schema(field.Type, constraints, false)
+			if src == "route" {
+				for _, p := range params {
+					if p.Name == name && reflect.DeepEqual(p.Schema, &Schema{Type: "string"}) {
+						p.Schema = s
+					}
+				}
+				continue
+			}
+			bound = append(bound, &OpenAPIParameter{
+				Name:     name,
+				In:       src,
+				Required: g.required(field.Type, constraints),
+				Schema:   s,
+			})
+		}
+	}
+	return bound
+}
+
+// hasBodyMembers reports whether model type t has any members which are
+// bound from the request body.
+func hasBodyMembers(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if hasBodyMembers(field.Type) {
+				return true
+			}
+			continue
+		}
+		if field.PkgPath == "" && field.Tag.Get("json") != "-" && !isBindingOnly(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAPIHandler returns a ContextHandlerFunc which sends the OpenAPI
+// document of the Router, generated when each request is made so that it
+// includes every registered route. The document is sent as YAML if the
+// request path ends in .yaml or .yml, or the request Accept header
+// prefers a YAML media type, and as JSON otherwise:
+//
+//	r.Get("/openapi.json", r.OpenAPIHandler(mango.OpenAPIInfo{
+//		Title:   "Fruit API",
+//		Version: "1.0.0",
+//	}))
+func (r *Router) OpenAPIHandler(info OpenAPIInfo) ContextHandlerFunc {
+	return func(c *Context) {
+		doc := r.OpenAPI(info)
+		ct := "application/json"
+		b, err := json.Marshal(doc)
+		if wantsYAML(c.Request) {
+			ct = "application/yaml"
+			b, err = doc.YAML()
+		}
+		if err != nil {
+			c.Error(http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		c.Respond().WithContentType(ct)
+		c.RespondWith(string(b))
+	}
+}
+
+// wantsYAML reports whether req is for an OpenAPI document in YAML.
+func wantsYAML(req *http.Request) bool {
+	if strings.HasSuffix(req.URL.Path, ".yaml") || strings.HasSuffix(req.URL.Path, ".yml") {
+		return true
+	}
+	for _, mt := range acceptableMediaTypes(req.Header.Get("Accept")) {
+		if yamlMediaTypes[mt] {
+			return true
+		}
+		if mt == "application/json" || mt == "*/*" {
+			return false
+		}
+	}
+	return false
+}
+
+// YAML returns the document encoded as YAML. Object members are sorted
+// by name, in the same way as they are by the encoding/json package.
+func (d *OpenAPI) YAML() ([]byte, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeYAML(&buf, v, 0, false)
+	return buf.Bytes(), nil
+}
+
+// writeYAML writes v, a value decoded from JSON, to buf as a YAML block
+// at the given indent. If inline is true, the first line of a mapping
+// follows a sequence item indicator, so is not indented.
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int, inline bool) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 || !inline {
+				buf.WriteString(pad)
+			}
+			buf.WriteString(yamlScalar(k) + ":")
+			writeYAMLValue(buf, v[k], indent+2)
+		}
+	case []interface{}:
+		for _, item := range v {
+			buf.WriteString(pad + "-")
+			if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+				buf.WriteString(" ")
+				writeYAML(buf, m, indent+2, true)
+				continue
+			}
+			writeYAMLValue(buf, item, indent+2)
+		}
+	}
+}
+
+// writeYAMLValue writes v following a mapping key or sequence item
+// indicator. Non-empty collections are written as blocks on the
+// following lines.
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		if len(c) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, c, indent, false)
+	case []interface{}:
+		if len(c) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, c, indent, false)
+	case string:
+		buf.WriteString(" " + yamlScalar(c) + "\n")
+	case nil:
+		buf.WriteString(" null\n")
+	case json.Number:
+		buf.WriteString(" " + c.String() + "\n")
+	case bool:
+		buf.WriteString(" " + strconv.FormatBool(c) + "\n")
+	}
+}
+
+// yamlPlain matches strings which can be written as plain YAML scalars
+// without being mistaken for another type.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$./+-]*$`)
+
+// yamlScalar returns s as a YAML scalar, quoting it if necessary.
+func yamlScalar(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if yamlPlain.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,189 @@
+package mango
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type openAPIFruit struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name" validate:"lenrange(2,20)"`
+	Colour string `json:"colour" validate:"inset(red,green)"`
+}
+
+type openAPIFruitQuery struct {
+	Page  int    `query:"page" validate:"min(1)"`
+	Token string `header:"X-Token" validate:"notempty"`
+}
+
+func openAPITestRouter() *Router {
+	r := NewRouter()
+	r.Get("/fruit/{id:int32}", testFunc).Models(nil, openAPIFruit{})
+	r.Put("/fruit/{id:int32}", testFunc).Models(openAPIFruit{}, openAPIFruit{})
+	r.Get("/fruit", testFunc).Models(openAPIFruitQuery{}, []openAPIFruit{})
+	r.Get("/files/{*path}", testFunc)
+	r.Get("/orders/{ref:uuid}/{n?}", testFunc)
+	return r
+}
+
+func TestRouterOpenAPIDescribesPaths(t *testing.T) {
+	want := map[string]string{
+		"/fruit/{id}":       "get,put",
+		"/fruit":            "get",
+		"/files/{path}":     "get",
+		"/orders/{ref}":     "get",
+		"/orders/{ref}/{n}": "get",
+	}
+	doc := openAPITestRouter().OpenAPI(OpenAPIInfo{Title: "Fruit", Version: "1.0"})
+	if doc.OpenAPI != OpenAPIVersion {
+		t.Errorf("OpenAPI = %q, want %q", doc.OpenAPI, OpenAPIVersion)
+	}
+	if len(doc.Paths) != len(want) {
+		t.Errorf("Path count = %d, want %d", len(doc.Paths), len(want))
+	}
+	for path, methods := range want {
+		for _, m := range strings.Split(methods, ",") {
+			if doc.Paths[path][m] == nil {
+				t.Errorf("%s %s: Operation = nil, want operation", m, path)
+			}
+		}
+	}
+}
+
+func TestRouterOpenAPIDescribesOperations(t *testing.T) {
+	tests := []struct {
+		path   string
+		method string
+		want   string
+	}{
+		{"/fruit/{id}", "get", `{"parameters":[{"name":"id","in":"path","required":true,"schema":{"type":"integer","format":"int32"}}],` +
+			`"responses":{"200":{"description":"OK","content":{"application/json":{"schema":{"$ref":"#/components/schemas/openAPIFruit"}}}}}}`},
+		{"/fruit/{id}", "put", `{"parameters":[{"name":"id","in":"path","required":true,"schema":{"type":"integer","format":"int32"}}],` +
+			`"requestBody":{"required":true,"content":{"application/json":{"schema":{"$ref":"#/components/schemas/openAPIFruit"}}}},` +
+			`"responses":{"200":{"description":"OK","content":{"application/json":{"schema":{"$ref":"#/components/schemas/openAPIFruit"}}}},` +
+			`"400":{"description":"Bad Request","content":{"application/json":{"schema":{"$ref":"#/components/schemas/BindFailure"}}}},` +
+			`"422":{"description":"Unprocessable Entity","content":{"application/json":{"schema":{"$ref":"#/components/schemas/BindFailure"}}}}}}`},
+		{"/fruit", "get", `{"parameters":[{"name":"page","in":"query","required":true,"schema":{"type":"integer","minimum":1}},` +
+			`{"name":"X-Token","in":"header","required":true,"schema":{"type":"string","minLength":1}}],` +
+			`"responses":{"200":{"description":"OK","content":{"application/json":{"schema":{"type":"array","items":{"$ref":"#/components/schemas/openAPIFruit"}}}}},` +
+			`"400":{"description":"Bad Request","content":{"application/json":{"schema":{"$ref":"#/components/schemas/BindFailure"}}}},` +
+			`"422":{"description":"Unprocessable Entity","content":{"application/json":{"schema":{"$ref":"#/components/schemas/BindFailure"}}}}}}`},
+		{"/files/{path}", "get", `{"parameters":[{"name":"path","in":"path","required":true,"schema":{"type":"string"}}],"responses":{"200":{"description":"OK"}}}`},
+		{"/orders/{ref}/{n}", "get", `{"parameters":[{"name":"ref","in":"path","required":true,"schema":{"type":"string","format":"uuid"}},` +
+			`{"name":"n","in":"path","required":true,"schema":{"type":"string"}}],"responses":{"200":{"description":"OK"}}}`},
+	}
+	doc := openAPITestRouter().OpenAPI(OpenAPIInfo{Title: "Fruit", Version: "1.0"})
+	for _, test := range tests {
+		b, err := json.Marshal(doc.Paths[test.path][test.method])
+		if err != nil {
+			t.Fatalf("Error = %q, want nil", err)
+		}
+		if got := string(b); got != test.want {
+			t.Errorf("%s %s: Operation = %s, want %s", test.method, test.path, got, test.want)
+		}
+	}
+}
+
+func TestRouterOpenAPIDescribesModelSchemas(t *testing.T) {
+	want := `{"type":"object","properties":{"colour":{"type":"string","enum":["red","green"]},"id":{"type":"integer","format":"int64"},` +
+		`"name":{"type":"string","minLength":2,"maxLength":20}},"required":["name","colour"]}`
+	doc := openAPITestRouter().OpenAPI(OpenAPIInfo{Title: "Fruit", Version: "1.0"})
+	if doc.Components == nil {
+		t.Fatalf("Components = nil, want components")
+	}
+	b, _ := json.Marshal(doc.Components.Schemas["openAPIFruit"])
+	if got := string(b); got != want {
+		t.Errorf("Schema = %s, want %s", got, want)
+	}
+}
+
+func TestRouterOpenAPIUsesRouteParamMemberSchema(t *testing.T) {
+	want := `[{"name":"id","in":"path","required":true,"schema":{"type":"integer","format":"int64"}}]`
+	r := NewRouter()
+	r.Delete("/fruit/{id}", testFunc).Models(typedRequest{}, nil)
+	doc := r.OpenAPI(OpenAPIInfo{Title: "Fruit", Version: "1.0"})
+	op := doc.Paths["/fruit/{id}"]["delete"]
+	b, _ := json.Marshal(op.Parameters)
+	if got := string(b); got != want {
+		t.Errorf("Parameters = %s, want %s", got, want)
+	}
+	if op.RequestBody != nil {
+		t.Errorf("RequestBody = %v, want nil", op.RequestBody)
+	}
+}
+
+func TestOpenAPIYAML(t *testing.T) {
+	want := `info:
+  title: Fruit
+  version: "1.0"
+openapi: "3.1.0"
+paths:
+  "/fruit/ripe":
+    get:
+      responses:
+        "200":
+          description: OK
+  "/fruit/{id}":
+    get:
+      parameters:
+        - in: path
+          name: id
+          required: true
+          schema:
+            format: int32
+            type: integer
+      responses:
+        "200":
+          description: OK
+`
+	r := NewRouter()
+	r.Get("/fruit/{id:int32}", testFunc)
+	r.Get("/fruit/ripe", testFunc)
+	b, err := r.OpenAPI(OpenAPIInfo{Title: "Fruit", Version: "1.0"}).YAML()
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("YAML = %s, want %s", got, want)
+	}
+}
+
+func TestRouterOpenAPIHandlerServesDocument(t *testing.T) {
+	tests := []struct {
+		path   string
+		accept string
+		ct     string
+		want   string
+	}{
+		{"/openapi", "", "application/json", `{"openapi":"3.1.0",`},
+		{"/openapi", "application/json", "application/json", `{"openapi":"3.1.0",`},
+		{"/openapi", "application/yaml", "application/yaml", "info:\n"},
+		{"/openapi", "text/html, application/x-yaml;q=0.8", "application/yaml", "info:\n"},
+		{"/openapi.yaml", "", "application/yaml", "info:\n"},
+	}
+	r := NewRouter()
+	r.Get("/fruit", testFunc)
+	info := OpenAPIInfo{Title: "Fruit", Version: "1.0"}
+	r.Get("/openapi", r.OpenAPIHandler(info))
+	r.Get("/openapi.yaml", r.OpenAPIHandler(info))
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Errorf("%s %q: Status = %d, want %d", test.path, test.accept, w.Code, 200)
+		}
+		if got := w.Header().Get("Content-Type"); got != test.ct {
+			t.Errorf("%s %q: Content-Type = %q, want %q", test.path, test.accept, got, test.ct)
+		}
+		if got := w.Body.String(); !strings.HasPrefix(got, test.want) {
+			t.Errorf("%s %q: Body = %q, want prefix %q", test.path, test.accept, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package mango
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingParam is the Err of a ParamError returned when the requested
+// route parameter or query value is not present in the request.
+var ErrMissingParam = errors.New("value missing")
+
+// ParamError records a failure to obtain a typed route parameter or
+// query value. Source is either "route" or "query", and Type is the
+// name of the requested type, e.g. "int64".
+type ParamError struct {
+	Source string
+	Name   string
+	Value  string
+	Type   string
+	Err    error
+}
+
+func (e ParamError) Error() string {
+	if e.Err == ErrMissingParam {
+		return fmt.Sprintf("%s parameter %q: %v", e.Source, e.Name, e.Err)
+	}
+	return fmt.Sprintf("%s parameter %q: cannot convert %q to %s: %v", e.Source, e.Name, e.Value, e.Type, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ParamError) Unwrap() error {
+	return e.Err
+}
+
+// UUID is a 128 bit universally unique identifier.
+type UUID [16]byte
+
+// ParseUUID converts s to a UUID. All the formats accepted by the uuid
+// route constraint can be parsed, i.e. with or without hyphens, and
+// optionally enclosed in braces or parentheses.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	h := s
+	if len(h) > 1 && (h[0] == '{' && h[len(h)-1] == '}' || h[0] == '(' && h[len(h)-1] == ')') {
+		h = h[1 : len(h)-1]
+	}
+	if len(h) == 36 {
+		if h[8] != '-' || h[13] != '-' || h[18] != '-' || h[23] != '-' {
+			return u, fmt.Errorf("invalid UUID: %q", s)
+		}
+		h = h[:8] + h[9:13] + h[14:18] + h[19:23] + h[24:]
+	}
+	if len(h) != 32 {
+		return u, fmt.Errorf("invalid UUID: %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, fmt.Errorf("invalid UUID: %q", s)
+	}
+	return u, nil
+}
+
+// String returns the UUID in its canonical, lowercase and hyphenated
+// form, e.g. 58d5e212-165b-4ca0-909b-c86b9cee0111.
+func (u UUID) String() string {
+	b := make([]byte, 36)
+	hex.Encode(b, u[:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (u *UUID) UnmarshalText(text []byte) error {
+	id, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = id
+	return nil
+}
+
+// ParamInt returns the named route parameter as an int.
+// Values which satisfied a hex, hex32 or hex64 route constraint are
+// parsed as hexadecimal. If the parameter is missing or cannot be
+// converted, a ParamError is returned.
+func (c *Context) ParamInt(name string) (int, error) {
+	i, err := c.paramInt("route", name, "int", strconv.IntSize)
+	return int(i), err
+}
+
+// ParamInt32 returns the named route parameter as an int32.
+// See ParamInt for more details.
+func (c *Context) ParamInt32(name string) (int32, error) {
+	i, err := c.paramInt("route", name, "int32", 32)
+	return int32(i), err
+}
+
+// ParamInt64 returns the named route parameter as an int64.
+// See ParamInt for more details.
+func (c *Context) ParamInt64(name string) (int64, error) {
+	return c.paramInt("route", name, "int64", 64)
+}
+
+// ParamFloat64 returns the named route parameter as a float64.
+// If the parameter is missing or cannot be converted, a ParamError
+// is returned.
+func (c *Context) ParamFloat64(name string) (float64, error) {
+	return c.paramFloat("route", name)
+}
+
+// ParamBool returns the named route parameter as a bool. Values are
+// parsed with strconv.ParseBool.
+// If the parameter is missing or cannot be converted, a ParamError
+// is returned.
+func (c *Context) ParamBool(name string) (bool, error) {
+	return c.paramBool("route", name)
+}
+
+// ParamUUID returns the named route parameter as a UUID.
+// If the parameter is missing or cannot be converted, a ParamError
+// is returned.
+func (c *Context) ParamUUID(name string) (UUID, error) {
+	return c.paramUUID("route", name)
+}
+
+// ParamTime returns the named route parameter as a time.Time, parsed
+// using layout. See time.Parse for more details.
+// If the parameter is missing or cannot be converted, a ParamError
+// is returned.
+func (c *Context) ParamTime(name, layout string) (time.Time, error) {
+	return c.paramTime("route", name, layout)
+}
+
+// QueryInt returns the first value of the named query parameter as an
+// int. If the parameter is missing or cannot be converted, a ParamError
+// is returned.
+func (c *Context) QueryInt(name string) (int, error) {
+	i, err := c.paramInt("query", name, "int", strconv.IntSize)
+	return int(i), err
+}
+
+// QueryInt32 returns the first value of the named query parameter as an
+// int32. See QueryInt for more details.
+func (c *Context) QueryInt32(name string) (int32, error) {
+	i, err := c.paramInt("query", name, "int32", 32)
+	return int32(i), err
+}
+
+// QueryInt64 returns the first value of the named query parameter as an
+// int64. See QueryInt for more details.
+func (c *Context) QueryInt64(name string) (int64, error) {
+	return c.paramInt("query", name, "int64", 64)
+}
+
+// QueryFloat64 returns the first value of the named query parameter as
+// a float64. See QueryInt for more details.
+func (c *Context) QueryFloat64(name string) (float64, error) {
+	return c.paramFloat("query", name)
+}
+
+// QueryBool returns the first value of the named query parameter as a
+// bool. Values are parsed with strconv.ParseBool. See QueryInt for more
+// details.
+func (c *Context) QueryBool(name string) (bool, error) {
+	return c.paramBool("query", name)
+}
+
+// QueryUUID returns the first value of the named query parameter as a
+// UUID. See QueryInt for more details.
+func (c *Context) QueryUUID(name string) (UUID, error) {
+	return c.paramUUID("query", name)
+}
+
+// QueryTime returns the first value of the named query parameter as a
+// time.Time, parsed using layout. See QueryInt for more details.
+func (c *Context) QueryTime(name, layout string) (time.Time, error) {
+	return c.paramTime("query", name, layout)
+}
+
+// param returns the named value from source, together with the route
+// route constraint it satisfied, if any.
+func (c *Context) param(source, name, typ string) (string, string, error) {
+	var v string
+	var ok bool
+	if source == "route" {
+		v, ok = c.RouteParams[name]
+	} else if c.Request != nil {
+		var vals []string
+		vals, ok = c.Request.URL.Query()[name]
+		if ok && len(vals) > 0 {
+			v = vals[0]
+		}
+	}
+	if !ok {
+		return "", "", ParamError{Source: source, Name: name, Type: typ, Err: ErrMissingParam}
+	}
+	if source != "route" {
+		// route constraints don't apply to query parameters
+		return v, "", nil
+	}
+	return v, c.paramConstraints[name], nil
+}
+
+// constraintTypes returns the names of the validators in constraint.
+func (c *Context) constraintTypes(constraint string) []string {
+	if constraint == "" {
+		return nil
+	}
+	var types []string
+	if c.router != nil && c.router.ValidationHandler != nil {
+		for k := range c.router.ValidationHandler.ParseConstraints(constraint) {
+			types = append(types, k)
+		}
+		return types
+	}
+	for _, s := range strings.Split(constraint, ",") {
+		if i := strings.IndexByte(s, '('); i >= 0 {
+			s = s[:i]
+		}
+		types = append(types, strings.TrimSpace(s))
+	}
+	return types
+}
+
+func (c *Context) paramInt(source, name, typ string, bits int) (int64, error) {
+	v, constraint, err := c.param(source, name, typ)
+	if err != nil {
+		return 0, err
+	}
+	base := 10
+	for _, t := range c.constraintTypes(constraint) {
+		if t == "hex" || t == "hex32" || t == "hex64" {
+			base = 16
+		}
+	}
+	i, err := strconv.ParseInt(v, base, bits)
+	if err != nil {
+		return 0, ParamError{source, name, v, typ, numError(err)}
+	}
+	return i, nil
+}
+
+func (c *Context) paramFloat(source, name string) (float64, error) {
+	v, _, err := c.param(source, name, "float64")
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, ParamError{source, name, v, "float64", numError(err)}
+	}
+	return f, nil
+}
+
+func (c *Context) paramBool(source, name string) (bool, error) {
+	v, _, err := c.param(source, name, "bool")
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, ParamError{source, name, v, "bool", numError(err)}
+	}
+	return b, nil
+}
+
+func (c *Context) paramUUID(source, name string) (UUID, error) {
+	v, _, err := c.param(source, name, "UUID")
+	if err != nil {
+		return UUID{}, err
+	}
+	u, err := ParseUUID(v)
+	if err != nil {
+		return UUID{}, ParamError{source, name, v, "UUID", errors.New("invalid syntax")}
+	}
+	return u, nil
+}
+
+func (c *Context) paramTime(source, name, layout string) (time.Time, error) {
+	v, _, err := c.param(source, name, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return time.Time{}, ParamError{source, name, v, "time", err}
+	}
+	return t, nil
+}
+
+// numError returns the reason for a strconv failure, without the
+// function name and value which ParamError already reports.
+func numError(err error) error {
+	if ne, ok := err.(*strconv.NumError); ok {
+		return ne.Err
+	}
+	return err
+}
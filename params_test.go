@@ -0,0 +1,218 @@
+package mango
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseUUID(t *testing.T) {
+	want := "58d5e212-165b-4ca0-909b-c86b9cee0111"
+	tests := []string{
+		"58d5e212-165b-4ca0-909b-c86b9cee0111",
+		"{58D5E212-165B-4CA0-909B-C86B9CEE0111}",
+		"(58d5e212-165b-4ca0-909b-c86b9cee0111)",
+		"58D5E212165B4CA0909BC86B9CEE0111",
+		"{58d5e212165b4ca0909bc86b9cee0111}",
+	}
+	for _, test := range tests {
+		u, err := ParseUUID(test)
+		if err != nil {
+			t.Errorf("ParseUUID(%q) error = %q, want nil", test, err)
+			continue
+		}
+		if got := u.String(); got != want {
+			t.Errorf("ParseUUID(%q) = %q, want %q", test, got, want)
+		}
+	}
+}
+
+func TestParseUUIDReturnsErrorWhenInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"58d5e212-165b-4ca0-909b-c86b9cee011",
+		"58d5e212-165b-4ca0-909b-c86b9cee01111",
+		"58d5e212+165b-4ca0-909b-c86b9cee0111",
+		"{58d5e212-165b-4ca0-909b-c86b9cee0111)",
+		"58d5e212-165b-4ca0-909b-c86b9cee011g",
+	}
+	for _, test := range tests {
+		if _, err := ParseUUID(test); err == nil {
+			t.Errorf("ParseUUID(%q) error = nil, want error", test)
+		}
+	}
+}
+
+func TestUUIDUnmarshalText(t *testing.T) {
+	want := "58d5e212-165b-4ca0-909b-c86b9cee0111"
+	var u UUID
+	err := u.UnmarshalText([]byte("58D5E212165B4CA0909BC86B9CEE0111"))
+	if err != nil {
+		t.Errorf("Error = %q, want nil", err)
+	}
+	if got := u.String(); got != want {
+		t.Errorf("UUID = %q, want %q", got, want)
+	}
+}
+
+func TestParamInt64(t *testing.T) {
+	tests := []struct {
+		constraint string
+		value      string
+		want       int64
+	}{
+		{"", "123", 123},
+		{"", "-123", -123},
+		{"int64", "123", 123},
+		{"hex64", "7b", 123},
+		{"hex32", "7B", 123},
+		{"hex", "ff", 255},
+		{"hex,lenmax(4)", "ff", 255},
+	}
+	for _, test := range tests {
+		c := Context{
+			RouteParams:      map[string]string{"id": test.value},
+			paramConstraints: map[string]string{"id": test.constraint},
+			router:           NewRouter(),
+		}
+		got, err := c.ParamInt64("id")
+		if err != nil {
+			t.Errorf("Error = %q, want nil", err)
+		}
+		if got != test.want {
+			t.Errorf("ParamInt64(%q, %q) = %d, want %d", test.constraint, test.value, got, test.want)
+		}
+	}
+}
+
+func TestParamIntReturnsParamErrorWhenInvalid(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"abc", `route parameter "id": cannot convert "abc" to int32: invalid syntax`},
+		{"3000000000", `route parameter "id": cannot convert "3000000000" to int32: value out of range`},
+	}
+	for _, test := range tests {
+		c := Context{RouteParams: map[string]string{"id": test.value}}
+		_, err := c.ParamInt32("id")
+		if _, ok := err.(ParamError); !ok {
+			t.Errorf("Error type = %T, want ParamError", err)
+			continue
+		}
+		if got := err.Error(); got != test.want {
+			t.Errorf("Error = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestParamReturnsErrMissingParamWhenMissing(t *testing.T) {
+	want := `route parameter "id": value missing`
+	c := Context{RouteParams: map[string]string{}}
+	_, err := c.ParamInt("id")
+	pe, ok := err.(ParamError)
+	if !ok {
+		t.Fatalf("Error type = %T, want ParamError", err)
+	}
+	if pe.Err != ErrMissingParam {
+		t.Errorf("Err = %q, want %q", pe.Err, ErrMissingParam)
+	}
+	if got := err.Error(); got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
+
+func TestParamTypedValues(t *testing.T) {
+	c := Context{RouteParams: map[string]string{
+		"price": "1.25",
+		"flag":  "true",
+		"id":    "{58D5E212-165B-4CA0-909B-C86B9CEE0111}",
+		"date":  "2017-06-21",
+	}}
+	if got, err := c.ParamFloat64("price"); err != nil || got != 1.25 {
+		t.Errorf("ParamFloat64 = %v, %v, want 1.25, nil", got, err)
+	}
+	if got, err := c.ParamBool("flag"); err != nil || !got {
+		t.Errorf("ParamBool = %v, %v, want true, nil", got, err)
+	}
+	if got, err := c.ParamUUID("id"); err != nil || got.String() != "58d5e212-165b-4ca0-909b-c86b9cee0111" {
+		t.Errorf("ParamUUID = %v, %v, want 58d5e212-165b-4ca0-909b-c86b9cee0111, nil", got, err)
+	}
+	want := time.Date(2017, 6, 21, 0, 0, 0, 0, time.UTC)
+	if got, err := c.ParamTime("date", "2006-01-02"); err != nil || !got.Equal(want) {
+		t.Errorf("ParamTime = %v, %v, want %v, nil", got, err, want)
+	}
+	if _, err := c.ParamTime("price", "2006-01-02"); err == nil {
+		t.Errorf("ParamTime error = nil, want error")
+	}
+}
+
+func TestQueryTypedValues(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/items?page=3&page=4&sort=&active=0", nil)
+	c := Context{Request: req}
+	if got, err := c.QueryInt("page"); err != nil || got != 3 {
+		t.Errorf("QueryInt = %v, %v, want 3, nil", got, err)
+	}
+	if got, err := c.QueryBool("active"); err != nil || got {
+		t.Errorf("QueryBool = %v, %v, want false, nil", got, err)
+	}
+	want := `query parameter "sort": cannot convert "" to int64: invalid syntax`
+	if _, err := c.QueryInt64("sort"); err == nil || err.Error() != want {
+		t.Errorf("Error = %v, want %q", err, want)
+	}
+	want = `query parameter "size": value missing`
+	if _, err := c.QueryInt64("size"); err == nil || err.Error() != want {
+		t.Errorf("Error = %v, want %q", err, want)
+	}
+}
+
+func TestRouterParamAccessorsUseMatchedConstraint(t *testing.T) {
+	want := "255|255"
+	got := ""
+	r := NewRouter()
+	r.Get("/items/{id:hex64}/{n}", func(c *Context) {
+		id, _ := c.ParamInt64("id")
+		n, _ := c.ParamInt64("n")
+		got = fmt.Sprintf("%d|%d", id, n)
+	})
+	req, _ := http.NewRequest("GET", "/items/ff/255", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got != want {
+		t.Errorf("Values = %q, want %q", got, want)
+	}
+}
+
+func TestRouterParamAccessorsUseHostConstraint(t *testing.T) {
+	want := int64(10)
+	var got int64
+	r := NewRouter()
+	r.Host("{shard:hex32}.example.com").Get("/items", func(c *Context) {
+		got, _ = c.ParamInt64("shard")
+	})
+	req, _ := http.NewRequest("GET", "http://a.example.com/items", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got != want {
+		t.Errorf("Value = %d, want %d", got, want)
+	}
+}
+
+func TestRouterQueryAccessorsIgnoreRouteConstraint(t *testing.T) {
+	want := "255|10"
+	got := ""
+	r := NewRouter()
+	r.Get("/items/{id:hex}", func(c *Context) {
+		id, _ := c.ParamInt("id")
+		q, _ := c.QueryInt("id")
+		got = fmt.Sprintf("%d|%d", id, q)
+	})
+	req, _ := http.NewRequest("GET", "/items/ff?id=10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got != want {
+		t.Errorf("Values = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package mango
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// PathAction determines what the Router does when a request path only
+// matches a registered route after normalization.
+type PathAction int
+
+const (
+	// PathExact requires request paths to match routes exactly. No
+	// normalization is performed.
+	PathExact PathAction = iota
+	// PathRedirect redirects the client to the normalized path, using
+	// 301 (Moved Permanently) for GET and HEAD requests and 308
+	// (Permanent Redirect) for all other methods.
+	PathRedirect
+	// PathRewrite handles the request as though it had been made to the
+	// normalized path, which replaces the path of the Context Request.
+	PathRewrite
+)
+
+// PathPolicy holds the path normalization configuration of a Router.
+// Normalization is only attempted when a request path does not match
+// any route exactly, so registered routes always take precedence.
+type PathPolicy struct {
+	// Action determines what happens when a normalized path matches
+	// a route. The default, PathExact, disables normalization.
+	Action PathAction
+	// TrailingSlash allows a path to match a route which differs only
+	// by the presence or absence of a trailing slash.
+	TrailingSlash bool
+	// CleanPath removes duplicate slashes and resolves any . and ..
+	// segments, before attempting to match a route. Static files served
+	// by the Router StaticDir are also subject to CleanPath.
+	CleanPath bool
+	// CaseInsensitive allows a path to match a route which differs only
+	// by the letter case of its static parts. Parameter values retain
+	// the case they had in the request.
+	CaseInsensitive bool
+}
+
+// normalizedPath returns the normalized version of p which matches a
+// route, according to the policy. It returns false if normalization is
+// disabled, or no normalized version of p matches.
+func (r *Router) normalizedPath(sels []selection, p string) (string, bool) {
+	pp := r.PathPolicy
+	if pp.Action == PathExact {
+		return "", false
+	}
+	candidates := []string{p}
+	if pp.CleanPath {
+		if cp := cleanPath(p); cp != p {
+			candidates[0] = cp
+		}
+	}
+	if pp.TrailingSlash {
+		candidates = append(candidates, toggleTrailingSlash(candidates[0]))
+	}
+	for _, c := range candidates {
+		if c == p {
+			continue
+		}
+		if _, ok := getResource(sels, c); ok {
+			return c, true
+		}
+	}
+	if pp.CaseInsensitive {
+		for _, s := range sels {
+			for _, c := range candidates {
+				if cp, ok := s.routes.CaseInsensitivePath(c); ok {
+					return cp, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// redirectPath redirects the client to path p, retaining any query.
+func (r *Router) redirectPath(w http.ResponseWriter, req *http.Request, p string) {
+	code := http.StatusPermanentRedirect
+	if req.Method == "GET" || req.Method == "HEAD" {
+		code = http.StatusMovedPermanently
+	}
+	u := *req.URL
+	u.Path = p
+	u.RawPath = ""
+	w.Header().Set("Location", u.RequestURI())
+	w.WriteHeader(code)
+}
+
+// serveStatic passes the request to the static file handler, cleaning
+// the path first if required by the policy.
+func (r *Router) serveStatic(w http.ResponseWriter, req *http.Request) {
+	pp := r.PathPolicy
+	if pp.Action != PathExact && pp.CleanPath {
+		if p := cleanPath(req.URL.Path); p != req.URL.Path {
+			if pp.Action == PathRedirect {
+				r.redirectPath(w, req, p)
+				return
+			}
+			req = withPath(req, p)
+		}
+	}
+	r.staticHandler.ServeHTTP(w, req)
+}
+
+// withPath returns a shallow copy of req with the URL path replaced by p.
+func withPath(req *http.Request, p string) *http.Request {
+	r := *req
+	u := *req.URL
+	u.Path = p
+	u.RawPath = ""
+	r.URL = &u
+	return &r
+}
+
+// cleanPath returns the canonical version of p, removing duplicate
+// slashes and resolving . and .. segments. Unlike path.Clean, any
+// trailing slash is retained.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cp := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cp != "/" {
+		cp += "/"
+	}
+	return cp
+}
+
+func toggleTrailingSlash(p string) string {
+	if p == "/" {
+		return p
+	}
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
@@ -0,0 +1,149 @@
+package mango
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathPolicyRedirectsNormalizedPaths(t *testing.T) {
+	tests := []struct {
+		policy PathPolicy
+		method string
+		path   string
+		want   string
+	}{
+		{PathPolicy{Action: PathRedirect, TrailingSlash: true}, "GET", "/users/", "301|/users"},
+		{PathPolicy{Action: PathRedirect, TrailingSlash: true}, "GET", "/users/42", "301|/users/42/"},
+		{PathPolicy{Action: PathRedirect, TrailingSlash: true}, "POST", "/users/", "308|/users"},
+		{PathPolicy{Action: PathRedirect, TrailingSlash: true}, "GET", "/users/?page=2", "301|/users?page=2"},
+		{PathPolicy{Action: PathRedirect, CleanPath: true}, "GET", "//users", "301|/users"},
+		{PathPolicy{Action: PathRedirect, CleanPath: true}, "GET", "/users/./42/../42/", "301|/users/42/"},
+		{PathPolicy{Action: PathRedirect, CaseInsensitive: true}, "GET", "/USERS/Jeff/", "301|/users/Jeff/"},
+		{PathPolicy{Action: PathRedirect, CleanPath: true, TrailingSlash: true, CaseInsensitive: true}, "GET", "//Users/", "301|/users"},
+		{PathPolicy{Action: PathRedirect}, "GET", "/users/", "404|"},
+		{PathPolicy{TrailingSlash: true, CleanPath: true, CaseInsensitive: true}, "GET", "/Users/", "404|"},
+	}
+	for _, test := range tests {
+		rtr := NewRouter()
+		rtr.PathPolicy = test.policy
+		rtr.Get("/users", testFunc)
+		rtr.Post("/users", testFunc)
+		rtr.Get("/users/{id}/", testFunc)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, "http://somewhere.com"+test.path, nil)
+		rtr.ServeHTTP(w, req)
+
+		got := fmt.Sprintf("%d|%s", w.Code, w.Header().Get("Location"))
+		if got != test.want {
+			t.Errorf("%s %q got %q, want %q", test.method, test.path, got, test.want)
+		}
+	}
+}
+
+func TestPathPolicyRewriteHandlesNormalizedPaths(t *testing.T) {
+	want := "200|Jeff"
+	rtr := NewRouter()
+	rtr.PathPolicy = PathPolicy{Action: PathRewrite, TrailingSlash: true, CaseInsensitive: true}
+	rtr.Get("/users/{name}", func(c *Context) {
+		c.RespondWith(c.RouteParams["name"])
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/Users/Jeff/", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestPathPolicyRewriteReplacesRequestPath(t *testing.T) {
+	want := "200|/a"
+	rtr := NewRouter()
+	rtr.PathPolicy = PathPolicy{Action: PathRewrite, CleanPath: true}
+	rtr.Mount("/admin/m", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin//m/a", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestPathPolicyPrefersExactMatch(t *testing.T) {
+	want := "testFunc2"
+	rtr := NewRouter()
+	rtr.PathPolicy = PathPolicy{Action: PathRewrite, TrailingSlash: true}
+	rtr.Get("/users", testFunc)
+	rtr.Get("/users/", func(c *Context) {
+		c.RespondWith("testFunc2")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Body.String()
+	if got != want {
+		t.Errorf("Handler got %q, want %q", got, want)
+	}
+}
+
+func TestPathPolicyCleansStaticFilePaths(t *testing.T) {
+	want := "mangoes"
+	dir, err := ioutil.TempDir("", "mango")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "fruit.txt"), []byte(want), 0644)
+
+	rtr := NewRouter()
+	rtr.PathPolicy = PathPolicy{Action: PathRewrite, CleanPath: true}
+	rtr.StaticDir(dir)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://somewhere.com//fruit.txt", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Body.String()
+	if got != want {
+		t.Errorf("Body got %q, want %q", got, want)
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", "/"},
+		{"/", "/"},
+		{"//", "/"},
+		{"/users", "/users"},
+		{"/users/", "/users/"},
+		{"users", "/users"},
+		{"//users//42", "/users/42"},
+		{"/users/./42/", "/users/42/"},
+		{"/users/../42", "/42"},
+		{"/../users", "/users"},
+	}
+	for _, test := range tests {
+		got := cleanPath(test.path)
+		if got != test.want {
+			t.Errorf("cleanPath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package mango
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+const (
+	// ProblemJSONMediaType is the media type of RFC 7807 problem details
+	// encoded as JSON.
+	ProblemJSONMediaType = "application/problem+json"
+	// ProblemXMLMediaType is the media type of RFC 7807 problem details
+	// encoded as XML.
+	ProblemXMLMediaType = "application/problem+xml"
+)
+
+// Problem holds the details of an error in an HTTP API response, as
+// described in RFC 7807. Type is a URI identifying the problem type,
+// and defaults to "about:blank" when empty, Title is a short summary
+// of the problem type, Status is the HTTP status code, Detail is an
+// explanation specific to this occurrence of the problem, and Instance
+// is a URI identifying the occurrence. Extensions holds any additional
+// members, which are encoded alongside the standard ones.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// NewProblem returns a new Problem for the HTTP status code, with the
+// standard status text as its Title.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	if p.Title == "" {
+		return p.Detail
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{})
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	for k, v := range p.members() {
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
+
+// MarshalXML implements the xml.Marshaler interface. The problem is
+// encoded in the urn:ietf:rfc:7807 namespace, with the extensions
+// following the standard members, sorted by name.
+func (p Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{
+		Name: xml.Name{Local: "problem"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "urn:ietf:rfc:7807"}},
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	members := p.members()
+	for _, k := range []string{"type", "title", "status", "detail", "instance"} {
+		if v, ok := members[k]; ok {
+			if err := e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+				return err
+			}
+		}
+	}
+	var keys []string
+	for k := range p.Extensions {
+		if _, ok := members[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := p.Extensions[k]
+		if f, ok := v.(map[string][]ValidationFailure); ok {
+			v = xmlFailures(f)
+		}
+		if err := e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// members returns the standard members of the problem which have been set.
+func (p Problem) members() map[string]interface{} {
+	m := make(map[string]interface{})
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return m
+}
+
+// Problem sends p as the response, encoded as application/problem+xml if
+// the request Accept header prefers XML, otherwise as
+// application/problem+json. If p.Status is zero, 500 is used.
+// Request handlers should cease execution after calling this method.
+func (c *Context) Problem(p *Problem) {
+	mt := ProblemJSONMediaType
+	var accept string
+	if c.Request != nil {
+		accept = c.Request.Header.Get("Accept")
+	}
+	for _, a := range acceptableMediaTypes(accept) {
+		if a == ProblemXMLMediaType || a == "application/xml" {
+			mt = ProblemXMLMediaType
+			break
+		}
+		if a == ProblemJSONMediaType || a == "application/json" {
+			break
+		}
+	}
+	writeProblem(c.Writer, p, mt)
+}
+
+// problemMediaType returns the problem media type preferred by the
+// request, or an empty string if the request does not accept one.
+func problemMediaType(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	for _, mt := range acceptableMediaTypes(req.Header.Get("Accept")) {
+		if mt == ProblemJSONMediaType || mt == ProblemXMLMediaType {
+			return mt
+		}
+	}
+	return ""
+}
+
+// writeProblem writes p to w, encoded according to the media type mt.
+func writeProblem(w http.ResponseWriter, p *Problem, mt string) {
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	var b []byte
+	var err error
+	if mt == ProblemXMLMediaType {
+		b, err = xml.Marshal(p)
+	} else {
+		b, err = json.Marshal(p)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("unable to encode problem: %v", err))
+	}
+	w.Header().Set("Content-Type", mt)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// httpError replies to the request with msg and the HTTP status code.
+// A problem is sent if the request accepts one, otherwise msg is sent
+// as plain text.
+func httpError(w http.ResponseWriter, req *http.Request, msg string, code int) {
+	if mt := problemMediaType(req); mt != "" {
+		if msg == http.StatusText(code) {
+			msg = ""
+		}
+		writeProblem(w, NewProblem(code, msg), mt)
+		return
+	}
+	http.Error(w, msg, code)
+}
@@ -0,0 +1,178 @@
+package mango
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProblemMarshalJSON(t *testing.T) {
+	want := `{"balance":30,"detail":"Your balance is 30.","instance":"/account/12345","status":403,"title":"Forbidden","type":"https://example.com/probs/out-of-credit"}`
+	p := Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "Forbidden",
+		Status:     403,
+		Detail:     "Your balance is 30.",
+		Instance:   "/account/12345",
+		Extensions: map[string]interface{}{"balance": 30, "status": 999},
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("JSON = %q, want %q", got, want)
+	}
+}
+
+func TestProblemMarshalXML(t *testing.T) {
+	want := `<problem xmlns="urn:ietf:rfc:7807"><title>Forbidden</title><status>403</status><detail>Your balance is 30.</detail><accounts>/account/1</accounts><balance>30</balance></problem>`
+	p := Problem{
+		Title:      "Forbidden",
+		Status:     403,
+		Detail:     "Your balance is 30.",
+		Extensions: map[string]interface{}{"balance": 30, "accounts": "/account/1"},
+	}
+	b, err := xml.Marshal(p)
+	if err != nil {
+		t.Fatalf("Error = %q, want nil", err)
+	}
+	if got := string(b); got != want {
+		t.Errorf("XML = %q, want %q", got, want)
+	}
+}
+
+func TestProblemError(t *testing.T) {
+	tests := []struct {
+		p    *Problem
+		want string
+	}{
+		{NewProblem(404, ""), "Not Found"},
+		{NewProblem(404, "no such mango"), "Not Found: no such mango"},
+		{&Problem{Detail: "no such mango"}, "no such mango"},
+	}
+	for _, test := range tests {
+		if got := test.p.Error(); got != test.want {
+			t.Errorf("Error = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestContextProblemNegotiatesMediaType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ProblemJSONMediaType},
+		{"*/*", ProblemJSONMediaType},
+		{"application/json", ProblemJSONMediaType},
+		{"application/xml", ProblemXMLMediaType},
+		{"application/problem+xml", ProblemXMLMediaType},
+		{"application/json;q=0.5, application/xml", ProblemXMLMediaType},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/mangos", nil)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		c := Context{Request: req, Writer: w}
+		c.Problem(NewProblem(409, "already ripe"))
+		if got := w.Header().Get("Content-Type"); got != test.want {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", test.accept, got, test.want)
+		}
+		if w.Code != 409 {
+			t.Errorf("Status = %d, want %d", w.Code, 409)
+		}
+	}
+}
+
+func TestContextErrorSendsProblemWhenAccepted(t *testing.T) {
+	want := `{"detail":"an error string","status":404,"title":"Not Found"}`
+	req, _ := http.NewRequest("GET", "/mangos", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+	c := Context{Request: req, Writer: w}
+	c.Error("an error string", 404)
+	if got := w.Body.String(); got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); got != ProblemJSONMediaType {
+		t.Errorf("Content-Type = %q, want %q", got, ProblemJSONMediaType)
+	}
+}
+
+func TestRouterSendsProblemsWhenAccepted(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		accept string
+		status int
+		want   string
+	}{
+		{"GET", "/nothing", ProblemJSONMediaType, 404,
+			`{"status":404,"title":"Not Found"}`},
+		{"DELETE", "/mangos", ProblemJSONMediaType, 405,
+			`{"status":405,"title":"Method Not Allowed"}`},
+		{"GET", "/panic", ProblemJSONMediaType, 500,
+			`{"status":500,"title":"Internal Server Error"}`},
+		{"GET", "/versioned", ProblemJSONMediaType + ", application/vnd.mango.v9+json", 406,
+			`{"detail":"No version of the resource matches the requested formats: \"application/problem+json, application/vnd.mango.v9+json\"","status":406,"title":"Not Acceptable"}`},
+		{"GET", "/nothing", ProblemXMLMediaType, 404,
+			`<problem xmlns="urn:ietf:rfc:7807"><title>Not Found</title><status>404</status></problem>`},
+		{"GET", "/nothing", "application/json", 404, "404 page not found\n"},
+	}
+	r := NewRouter()
+	r.Get("/mangos", func(c *Context) {})
+	r.Get("/panic", func(c *Context) { panic("oops") })
+	r.Version("2", "application/vnd.mango.v2+json").Get("/versioned", func(c *Context) {})
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s %s: Status = %d, want %d", test.method, test.path, w.Code, test.status)
+		}
+		if got := w.Body.String(); got != test.want {
+			t.Errorf("%s %s: Body = %q, want %q", test.method, test.path, got, test.want)
+		}
+	}
+}
+
+func TestBindAndValidateSendsProblemWhenAccepted(t *testing.T) {
+	tests := []struct {
+		body   string
+		ct     string
+		status int
+		want   string
+	}{
+		{`{"name":"Mango59"}`, "application/json", 422,
+			`{"detail":"The request model failed validation.","failures":{"name":[{"code":"alpha","message":"must contain only alpha characters."}]},"status":422,"title":"Unprocessable Entity"}`},
+		{`name=Mango`, "text/plain", 415,
+			`{"detail":"unsupported media type (Content-Type: text/plain)","status":415,"title":"Unsupported Media Type"}`},
+	}
+	for _, test := range tests {
+		r := NewRouter()
+		r.Post("/fruit", func(c *Context) {
+			m := bindAndValidateModel{}
+			c.BindAndValidate(&m)
+		})
+		req, _ := http.NewRequest("POST", "/fruit", bytes.NewBufferString(test.body))
+		req.Header.Set("Content-Type", test.ct)
+		req.Header.Set("Accept", ProblemJSONMediaType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("Status = %d, want %d", w.Code, test.status)
+		}
+		if got := w.Header().Get("Content-Type"); got != ProblemJSONMediaType {
+			t.Errorf("Content-Type = %q, want %q", got, ProblemJSONMediaType)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != test.want {
+			t.Errorf("Body = %q, want %q", got, test.want)
+		}
+	}
+}
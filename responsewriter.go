@@ -5,7 +5,9 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -39,6 +41,8 @@ type ResponseWriter struct {
 	headersSent      bool
 	compMinLength    int
 	acceptedEncoding string
+	headOnly         bool
+	headLength       int
 }
 
 // Header returns the header map that will be sent by
@@ -70,6 +74,10 @@ func (r *ResponseWriter) WriteHeader(status int) {
 	r.headersSent = true
 	r.responded = true
 	r.status = status
+	if r.headOnly {
+		// sent by finish, once the content length is known
+		return
+	}
 	r.rw.WriteHeader(status)
 }
 
@@ -108,7 +116,6 @@ func (r *ResponseWriter) Write(b []byte) (int, error) {
 	if r.readonly {
 		return 0, fmt.Errorf("write method has been called already")
 	}
-
 	reader, writer := io.Pipe()
 	go func() {
 		defer writer.Close()
@@ -121,12 +128,54 @@ func (r *ResponseWriter) Write(b []byte) (int, error) {
 		}
 	}()
 
+	// a head only response discards the body, but keeps count
+	// of it for the Content-Length
+	var w io.Writer = r.rw
+	if r.headOnly {
+		w = ioutil.Discard
+	}
+
 	// TODO: check the error before updating anything
-	bc, err := io.Copy(r.rw, reader)
+	bc, err := io.Copy(w, reader)
 	i := int(bc)
 
-	r.byteCount += i
+	if r.headOnly {
+		r.headLength += i
+	} else {
+		r.byteCount += i
+	}
 	r.headersSent = true
 	r.responded = true
 	return i, err
 }
+
+// resetHead discards the status, and any Content-Encoding, recorded for
+// a head only response, so that a different response can be sent in its
+// place. It returns false if r is not a head only response.
+func (r *ResponseWriter) resetHead() bool {
+	if !r.headOnly {
+		return false
+	}
+	r.headersSent = false
+	r.responded = false
+	r.status = 200
+	r.headLength = 0
+	r.rw.Header().Del("Content-Encoding")
+	return true
+}
+
+// finish sends the headers of a head only response, setting the
+// Content-Length header to the number of bytes the body would have
+// contained, unless it has been set already.
+func (r *ResponseWriter) finish() {
+	if !r.headOnly {
+		return
+	}
+	r.headOnly = false
+	h := r.rw.Header()
+	if h.Get("Content-Length") == "" && r.status != http.StatusNoContent &&
+		r.status != http.StatusNotModified {
+		h.Set("Content-Length", strconv.Itoa(r.headLength))
+	}
+	r.rw.WriteHeader(r.status)
+}
@@ -3,6 +3,7 @@ package mango
 import (
 	"compress/flate"
 	"compress/gzip"
+	"fmt"
 	"io/ioutil"
 	"net/http/httptest"
 	"testing"
@@ -408,3 +409,67 @@ func TestResponseWriterWriteReturnsCompressedBytesWrittenWhenDeflateAccepted(t *
 		t.Errorf("Bytes written = %d, want less than %d", got, want)
 	}
 }
+
+func TestResponseWriterHeadOnlyDiscardsBody(t *testing.T) {
+	want := ""
+	w := httptest.NewRecorder()
+	resp := NewResponseWriter(w, "", 0)
+	resp.headOnly = true
+	resp.Write([]byte("mangoes in the morning"))
+	resp.finish()
+
+	got := w.Body.String()
+
+	if got != want {
+		t.Errorf("Body = %q, want %q", got, want)
+	}
+}
+
+func TestResponseWriterHeadOnlySetsContentLengthWhenFinished(t *testing.T) {
+	want := "22"
+	w := httptest.NewRecorder()
+	resp := NewResponseWriter(w, "", 0)
+	resp.headOnly = true
+	resp.Write([]byte("mangoes in the morning"))
+	resp.finish()
+
+	got := w.Header().Get("Content-Length")
+
+	if got != want {
+		t.Errorf("Content-Length = %q, want %q", got, want)
+	}
+}
+
+func TestResponseWriterHeadOnlySetsCompressedContentLengthWhenFinished(t *testing.T) {
+	body := []byte("mangoes in the morning")
+	get := httptest.NewRecorder()
+	NewResponseWriter(get, "gzip", 1).Write(body)
+	want := fmt.Sprintf("gzip|%d", get.Body.Len())
+	w := httptest.NewRecorder()
+	resp := NewResponseWriter(w, "gzip", 1)
+	resp.headOnly = true
+	resp.Write(body)
+	resp.finish()
+
+	got := fmt.Sprintf("%s|%s", w.Header().Get("Content-Encoding"), w.Header().Get("Content-Length"))
+
+	if got != want {
+		t.Errorf("Headers = %q, want %q", got, want)
+	}
+}
+
+func TestResponseWriterHeadOnlySendsStatusWhenFinished(t *testing.T) {
+	want := 201
+	w := httptest.NewRecorder()
+	resp := NewResponseWriter(w, "", 0)
+	resp.headOnly = true
+	resp.WriteHeader(201)
+	resp.Header().Set("X-Mango", "ripe")
+	resp.finish()
+
+	got := w.Code
+
+	if got != want {
+		t.Errorf("Status = %d, want %d", got, want)
+	}
+}
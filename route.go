@@ -0,0 +1,239 @@
+package mango
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Route is returned by the route registration methods (Get, Post etc.)
+// and can be used to configure the newly registered route further.
+type Route struct {
+	router   *Router
+	pattern  string
+	method   string
+	selector *selector
+	version  *VersionedHandler
+}
+
+// Name assigns a name to the route pattern, which can then be used to
+// generate URLs with the Router URL method or Context URLFor method.
+// The same name can be used for more than one method of a pattern, but
+// Name panics if the name has already been assigned to a different pattern.
+// This method returns the Route object and can be chained.
+func (rt *Route) Name(name string) *Route {
+	r := rt.router
+	if p, ok := r.namedRoutes[name]; ok && p != rt.pattern {
+		panic(fmt.Sprintf("duplicate route name: %q (%s, %s)", name, p, rt.pattern))
+	}
+	if r.namedRoutes == nil {
+		r.namedRoutes = make(map[string]string)
+	}
+	r.namedRoutes[name] = rt.pattern
+	return rt
+}
+
+// Models records the types of the request and response models of the
+// route, which are reported by the Router Routes method for use in API
+// documentation. Either can be nil if the route has no such model.
+// The models of routes registered using TypedRoute are recorded
+// automatically, but can be replaced using Models.
+// This method returns the Route object and can be chained.
+func (rt *Route) Models(req, res interface{}) *Route {
+	rt.setModels(reflect.TypeOf(req), reflect.TypeOf(res))
+	return rt
+}
+
+func (rt *Route) setModels(req, res reflect.Type) {
+	r := rt.router
+	if r.routeModels == nil {
+		r.routeModels = make(map[routeKey]routeModels)
+	}
+	k := routeKey{method: rt.method, pattern: rt.pattern, selector: rt.selector}
+	if rt.version != nil {
+		k.version, k.mediaType = rt.version.Version, rt.version.MediaType
+	}
+	r.routeModels[k] = routeModels{request: req, response: res}
+}
+
+// routeKey identifies a registered route, including any host or header
+// selector and version, as several routes can share a pattern and method.
+type routeKey struct {
+	method    string
+	pattern   string
+	selector  *selector
+	version   string
+	mediaType string
+}
+
+// routeModels holds the model types recorded for a route.
+type routeModels struct {
+	request  reflect.Type
+	response reflect.Type
+}
+
+// RouteInfo holds details about a registered route.
+type RouteInfo struct {
+	// Pattern is the route pattern, including any parameters.
+	Pattern string
+	// Method is the HTTP method of the route.
+	Method string
+	// Name is the name assigned to the route pattern, if any.
+	Name string
+	// ParamNames lists the pattern parameter names, in the order
+	// they appear in the pattern.
+	ParamNames []string
+	// Constraints maps each parameter name to its constraint. Parameters
+	// without a constraint map to an empty string.
+	Constraints map[string]string
+	// Handler is the name of the handler function.
+	Handler string
+	// Middleware lists the names of any Middleware attached to the route,
+	// in the order they are called.
+	Middleware []string
+	// CORSConfig is the effective CORS configuration of the route, which
+	// may be the global configuration. CORSConfig may be nil.
+	CORSConfig *CORSConfig
+	// Host is the host pattern the route is restricted to, if any.
+	Host string
+	// Headers holds the header values the route is restricted to, if any.
+	Headers map[string]string
+	// Version and MediaType are set for routes which are selected by the
+	// request version header or media type.
+	Version   string
+	MediaType string
+	// Request and Response are the types of the request and response
+	// models recorded for the route with the Route Models method or
+	// TypedRoute, if any.
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+type routeInfos []RouteInfo
+
+func (r routeInfos) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r routeInfos) Len() int      { return len(r) }
+func (r routeInfos) Less(i, j int) bool {
+	if r[i].Pattern != r[j].Pattern {
+		return r[i].Pattern < r[j].Pattern
+	}
+	if r[i].Method != r[j].Method {
+		return r[i].Method < r[j].Method
+	}
+	return r[i].Version < r[j].Version
+}
+
+// Routes returns details of every registered route, sorted by pattern,
+// method and then version. Routes restricted to a host or header values follow
+// the unrestricted routes, in the order their Host or Header groups
+// were created.
+func (r *Router) Routes() []RouteInfo {
+	routes := r.routes.Routes()
+	r.addModels(routes, nil)
+	for _, s := range r.selectors {
+		srs := s.routes.Routes()
+		r.addModels(srs, s)
+		for _, ri := range srs {
+			ri.Host = s.host
+			ri.Headers = s.headers
+			routes = append(routes, ri)
+		}
+	}
+	for name, pattern := range r.namedRoutes {
+		// patterns with optional parameters are held as several routes
+		for _, e := range expandOptional(pattern) {
+			for i := range routes {
+				if routes[i].Pattern == e.pattern {
+					routes[i].Name = name
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// addModels sets the model types recorded for the routes of sel, or of
+// the Router itself if sel is nil.
+func (r *Router) addModels(routes []RouteInfo, sel *selector) {
+	for k, m := range r.routeModels {
+		if k.selector != sel {
+			continue
+		}
+		// patterns with optional parameters are held as several routes
+		for _, e := range expandOptional(k.pattern) {
+			for i := range routes {
+				ri := &routes[i]
+				if ri.Pattern == e.pattern && ri.Method == k.method &&
+					ri.Version == k.version && ri.MediaType == k.mediaType {
+					ri.Request = m.request
+					ri.Response = m.response
+				}
+			}
+		}
+	}
+}
+
+// URL returns the path of the named route, substituting the pattern
+// parameters with the values in params. Parameter values are escaped
+// and validated against the parameter constraints.
+// An error is returned if the route name is unknown, or a parameter
+// value is missing or does not satisfy its constraint.
+func (r *Router) URL(name string, params map[string]string) (string, error) {
+	pattern, ok := r.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("unknown route name: %q", name)
+	}
+	path := ""
+	for {
+		i := strings.IndexByte(pattern, byte('{'))
+		if i < 0 {
+			break
+		}
+		path += pattern[:i]
+		pattern = pattern[i+1:]
+		i = paramEnd(pattern)
+		pn, constraint, catchAll := parseParam(pattern[:i])
+		pattern = pattern[i+1:]
+		pn, _, optional := optionalParam(pn)
+
+		value, ok := params[pn]
+		if !ok && optional {
+			// omit this, and any following optional parameters
+			if path = strings.TrimSuffix(path, "/"); path == "" {
+				path = "/"
+			}
+			return path, nil
+		}
+		if !ok {
+			return "", fmt.Errorf("missing value for route parameter %q in route %q", pn, name)
+		}
+		if r.ValidationHandler != nil {
+			if fails, ok := r.ValidationHandler.IsValid(value, constraint); !ok {
+				return "", fmt.Errorf("invalid value %q for route parameter %q in route %q: %s",
+					value, pn, name, fails[0].Message)
+			}
+		}
+		path += escapeParam(value, catchAll)
+	}
+	return path + pattern, nil
+}
+
+func escapeParam(value string, catchAll bool) string {
+	if !catchAll {
+		return escapeSegment(value)
+	}
+	// retain the separators in catch-all values
+	segs := strings.Split(value, "/")
+	for i, s := range segs {
+		segs[i] = escapeSegment(s)
+	}
+	return strings.Join(segs, "/")
+}
+
+// escapeSegment escapes s for use as a single path segment. It is
+// equivalent to url.PathEscape, which requires Go 1.8.
+func escapeSegment(s string) string {
+	p := (&url.URL{Path: s}).EscapedPath()
+	return strings.Replace(p, "/", "%2F", -1)
+}
@@ -0,0 +1,199 @@
+package mango
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestRouterURLReturnsPathOfNamedRoute(t *testing.T) {
+	want := "/users/42/posts"
+	r := NewRouter()
+	r.Get("/users/{id:int32}/posts", testFunc).Name("user-posts")
+	got, err := r.URL("user-posts", map[string]string{"id": "42"})
+	if err != nil {
+		t.Errorf("Error = %q, want nil", err)
+	}
+	if got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestRouterURLReturnsPathOfNamedGroupRoute(t *testing.T) {
+	want := "/api/v1/users/42"
+	r := NewRouter()
+	r.Group("/api/v1").Get("/users/{id}", testFunc).Name("user-detail")
+	got, _ := r.URL("user-detail", map[string]string{"id": "42"})
+	if got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestRouterURLEscapesParameterValues(t *testing.T) {
+	want := "/users/jeff%20smith%2F100%25/files/a%20b/c%3F.txt"
+	r := NewRouter()
+	r.Get("/users/{name}/files/{*path}", testFunc).Name("files")
+	got, _ := r.URL("files", map[string]string{"name": "jeff smith/100%", "path": "a b/c?.txt"})
+	if got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestRouterURLReturnsErrorWhenNameUnknown(t *testing.T) {
+	want := `unknown route name: "user-detail"`
+	r := NewRouter()
+	_, err := r.URL("user-detail", nil)
+	if err == nil {
+		t.Errorf("Error = nil, want %q", want)
+		return
+	}
+	got := err.Error()
+	if got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
+
+func TestRouterURLReturnsErrorWhenParameterMissing(t *testing.T) {
+	want := `missing value for route parameter "id" in route "user-detail"`
+	r := NewRouter()
+	r.Get("/users/{id:int32}", testFunc).Name("user-detail")
+	_, err := r.URL("user-detail", map[string]string{"name": "42"})
+	if err == nil {
+		t.Errorf("Error = nil, want %q", want)
+		return
+	}
+	got := err.Error()
+	if got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
+
+func TestRouterURLReturnsErrorWhenParameterFailsConstraint(t *testing.T) {
+	want := `invalid value "fish" for route parameter "id" in route "user-detail": must be a 32 bit integer.`
+	r := NewRouter()
+	r.Get("/users/{id:int32}", testFunc).Name("user-detail")
+	_, err := r.URL("user-detail", map[string]string{"id": "fish"})
+	if err == nil {
+		t.Errorf("Error = nil, want %q", want)
+		return
+	}
+	got := err.Error()
+	if got != want {
+		t.Errorf("Error = %q, want %q", got, want)
+	}
+}
+
+func TestRouteNameCanBeSharedByMethodsOfSamePattern(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("The code panicked: %v", r)
+		}
+	}()
+	r := NewRouter()
+	r.Get("/users/{id}", testFunc).Name("user-detail")
+	r.Put("/users/{id}", testFunc).Name("user-detail")
+}
+
+func TestRouteNamePanicsWhenNameUsedForDifferentPattern(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	r := NewRouter()
+	r.Get("/users/{id}", testFunc).Name("user-detail")
+	r.Get("/people/{id}", testFunc).Name("user-detail")
+}
+
+func TestContextURLForReturnsAbsoluteURL(t *testing.T) {
+	want := "https://somewhere.com/users/42"
+	r := NewRouter()
+	r.Get("/users/{id:int32}", testFunc).Name("user-detail")
+	req, _ := http.NewRequest("GET", "https://somewhere.com/users", nil)
+	req.TLS = &tls.ConnectionState{}
+	c := Context{Request: req, router: r}
+	got, err := c.URLFor("user-detail", map[string]string{"id": "42"})
+	if err != nil {
+		t.Errorf("Error = %q, want nil", err)
+	}
+	if got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestContextURLForReturnsErrorWhenRouteInvalid(t *testing.T) {
+	r := NewRouter()
+	req, _ := http.NewRequest("GET", "http://somewhere.com/users", nil)
+	c := Context{Request: req, router: r}
+	_, err := c.URLFor("user-detail", nil)
+	if err == nil {
+		t.Errorf("Error = nil, want error")
+	}
+}
+
+func TestRouterRoutesIncludesRouteNames(t *testing.T) {
+	want := "user-detail,user-detail,"
+	r := NewRouter()
+	r.Get("/users/{id:int32}", testFunc).Name("user-detail")
+	r.Put("/users/{id:int32}", testFunc)
+	r.Get("/users", testFunc)
+	got := ""
+	for _, rt := range r.Routes() {
+		if rt.Name != "" {
+			got += rt.Name + ","
+		}
+	}
+	if got != want {
+		t.Errorf("Names = %q, want %q", got, want)
+	}
+}
+
+func TestRouterURLOmitsMissingOptionalParameters(t *testing.T) {
+	tests := []struct {
+		params map[string]string
+		want   string
+	}{
+		{map[string]string{"year": "2017", "month": "6", "page": "2"}, "/reports/2017/6/2"},
+		{map[string]string{"year": "2017", "month": "6"}, "/reports/2017/6"},
+		{map[string]string{"year": "2017", "page": "2"}, "/reports/2017"},
+	}
+	r := NewRouter()
+	r.Get("/reports/{year:int32}/{month?:int32}/{page=1:int32}", testFunc).Name("reports")
+	for _, test := range tests {
+		got, err := r.URL("reports", test.params)
+		if err != nil {
+			t.Errorf("Error = %q, want nil", err)
+		}
+		if got != test.want {
+			t.Errorf("URL = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestRouterURLReturnsRootWhenOnlyOptionalParameterMissing(t *testing.T) {
+	want := "/"
+	r := NewRouter()
+	r.Get("/{page=1:int32}", testFunc).Name("home")
+
+	got, err := r.URL("home", nil)
+	if err != nil {
+		t.Errorf("Error = %q, want nil", err)
+	}
+	if got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestRouterRoutesNamesOptionalParameterRoutes(t *testing.T) {
+	want := "reports,reports"
+	r := NewRouter()
+	r.Get("/reports/{year:int32}/{month?:int32}", testFunc).Name("reports")
+	got := ""
+	for _, rt := range r.Routes() {
+		got += "," + rt.Name
+	}
+	got = got[1:]
+	if got != want {
+		t.Errorf("Names = %q, want %q", got, want)
+	}
+}
@@ -8,11 +8,15 @@ import (
 )
 
 type routes interface {
-	AddHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc)
+	AddHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc, mw ...Middleware)
+	AddRegistration(pattern, method string, reg registration)
+	AddVersionedHandlerFunc(pattern, method string, vh VersionedHandler)
 	GetResource(path string) (*Resource, bool)
 	SetGlobalCORS(config CORSConfig)
 	SetCORS(pattern string, config CORSConfig)
 	AddCORS(pattern string, config CORSConfig)
+	Routes() []RouteInfo
+	CaseInsensitivePath(path string) (string, bool)
 }
 
 // RequestLogFunc is the signature for implementing router RequestLogger
@@ -36,6 +40,60 @@ type Router struct {
 	modelValidator           ModelValidator
 	CompMinLength            int
 	staticHandler            http.Handler
+	namedRoutes              map[string]string
+	routeModels              map[routeKey]routeModels
+	selectors                []*selector
+	globalCORS               *CORSConfig
+	// NotFoundHandler is called when no route matches the request path.
+	// If nil, a plain text 404 response is sent.
+	NotFoundHandler ContextHandlerFunc
+	// MethodNotAllowedHandler is called when a route matches the request
+	// path, but has no handler for the request method. The Allow header
+	// will already have been set if AutoPopulateOptionsAllow is true.
+	// If nil, an empty 405 response is sent.
+	MethodNotAllowedHandler ContextHandlerFunc
+	// PanicHandler is called when a handler panics, providing the response
+	// has not already been started. The recovered value and stack trace are
+	// available from the Context Recovered method.
+	// If nil, a plain text 500 response is sent.
+	PanicHandler ContextHandlerFunc
+	// AutoHandleHead causes HEAD requests to resources which have a GET
+	// handler, but no HEAD handler, to be handled by the GET handler. The
+	// response headers, including Content-Length, are sent but the body
+	// is discarded.
+	AutoHandleHead bool
+	// PathPolicy determines how request paths which only match a route
+	// after normalization, such as removing a trailing slash, are handled.
+	// By default, paths must match routes exactly.
+	PathPolicy PathPolicy
+	// VersionHeader is the name of the request header which can be used
+	// to select the version of a route registered through a Version group.
+	// If empty, versions are only selected by the Accept header.
+	VersionHeader string
+	// MaxMultipartMemory is the number of bytes of multipart/form-data
+	// request content held in memory when binding or retrieving files.
+	// Any remaining file content is spooled to temporary files, which are
+	// removed once the response has been sent. NewRouter sets
+	// MaxMultipartMemory to 32 MB.
+	MaxMultipartMemory int64
+	// MaxMultipartSize limits the size of multipart/form-data request
+	// bodies. Requests exceeding it fail to bind. If zero, there is no
+	// limit.
+	MaxMultipartSize int64
+	// BindFailureStatus is the response status code used by the Context
+	// BindAndValidate method when a request cannot be bound to a model.
+	// NewRouter sets BindFailureStatus to 400 (Bad Request).
+	BindFailureStatus int
+	// ValidationFailureStatus is the response status code used by the
+	// Context BindAndValidate method when a model fails validation.
+	// NewRouter sets ValidationFailureStatus to 422 (Unprocessable Entity).
+	ValidationFailureStatus int
+	// BindFailureModel, if set, returns the response model used by the
+	// Context BindAndValidate method. If nil, a BindFailure is used.
+	BindFailureModel BindFailureFunc
+	// ErrorMapper sends the response for errors returned by handlers
+	// registered using HandleErrors. If nil, DefaultErrorMapper is used.
+	ErrorMapper ErrorMapper
 }
 
 // AddModelValidator adds a custom model validator to the collection.
@@ -55,6 +113,9 @@ func NewRouter() *Router {
 	r.EncoderEngine = newEncoderEngine()
 	r.AutoPopulateOptionsAllow = true
 	r.CompMinLength = 300
+	r.MaxMultipartMemory = defaultMultipartMemory
+	r.BindFailureStatus = http.StatusBadRequest
+	r.ValidationFailureStatus = http.StatusUnprocessableEntity
 	return &r
 }
 
@@ -63,7 +124,11 @@ func NewRouter() *Router {
 // resource has no CORSConfig and tree.GlobalCORSConfig is nil
 // then CORS request are treated like any other.
 func (r *Router) SetGlobalCORS(config CORSConfig) {
+	r.globalCORS = &config
 	r.routes.SetGlobalCORS(config)
+	for _, s := range r.selectors {
+		s.routes.SetGlobalCORS(config)
+	}
 }
 
 // SetCORS sets the CORS configuration that will be used for
@@ -84,57 +149,70 @@ func (r *Router) AddCORS(pattern string, config CORSConfig) {
 
 // Get registers a new handlerFunc that will be called when HTTP GET
 // requests are made to URLs with paths that match pattern.
+// Any middleware supplied is called before handlerFunc, in the order
+// listed.
 // If a GET handlerFunc already exists for pattern, Get panics.
-func (r *Router) Get(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "GET", handlerFunc)
+func (r *Router) Get(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "GET", handlerFunc, mw)
 }
 
 // Post registers a new handlerFunc that will be called when HTTP POST
 // requests are made to URLs with paths that match pattern.
 // If a POST handlerFunc already exists for pattern, Post panics.
-func (r *Router) Post(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "POST", handlerFunc)
+func (r *Router) Post(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "POST", handlerFunc, mw)
 }
 
 // Put registers a new handlerFunc that will be called when HTTP PUT
 // requests are made to URLs with paths that match pattern.
 // If a PUT handlerFunc already exists for pattern, Put panics.
-func (r *Router) Put(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "PUT", handlerFunc)
+func (r *Router) Put(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "PUT", handlerFunc, mw)
 }
 
 // Patch registers a new handlerFunc that will be called when HTTP PATCH
 // requests are made to URLs with paths that match pattern.
 // If a PATCH handlerFunc already exists for pattern, Patch panics.
-func (r *Router) Patch(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "PATCH", handlerFunc)
+func (r *Router) Patch(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "PATCH", handlerFunc, mw)
 }
 
 // Delete registers a new handlerFunc that will be called when HTTP DELETE
 // requests are made to URLs with paths that match pattern.
 // If a DELETE handlerFunc already exists for pattern, Delete panics.
-func (r *Router) Delete(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "DELETE", handlerFunc)
+func (r *Router) Delete(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "DELETE", handlerFunc, mw)
 }
 
 // Head registers a new handlerFunc that will be called when HTTP HEAD
 // requests are made to URLs with paths that match pattern.
 // If a HEAD handlerFunc already exists for pattern, Head panics.
-func (r *Router) Head(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "HEAD", handlerFunc)
+func (r *Router) Head(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "HEAD", handlerFunc, mw)
 }
 
 // Options registers a new handlerFunc that will be called when HTTP OPTIONS
 // requests are made to URLs with paths that match pattern.
 // If a OPTIONS handlerFunc already exists for pattern, Options panics.
-func (r *Router) Options(pattern string, handlerFunc ContextHandlerFunc) {
-	r.routes.AddHandlerFunc(pattern, "OPTIONS", handlerFunc)
+func (r *Router) Options(pattern string, handlerFunc ContextHandlerFunc, mw ...Middleware) *Route {
+	return r.addHandlerFunc(pattern, "OPTIONS", handlerFunc, mw)
+}
+
+func (r *Router) addHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc, mw []Middleware) *Route {
+	return r.addRegistration(pattern, method, registration{handlerFunc: handlerFunc, middleware: mw})
+}
+
+func (r *Router) addRegistration(pattern, method string, reg registration) *Route {
+	r.routes.AddRegistration(pattern, method, reg)
+	return &Route{router: r, pattern: pattern, method: method}
 }
 
 // StaticDir sets a root directory for serving static files.
 // Root can be an absolute or relative directory path.
 // As a special case, any request ending in "/index.html" is redirected to the
 // same path, without the final "index.html".
+// If the PathPolicy has CleanPath set, request paths are cleaned before
+// the file is located.
 func (r *Router) StaticDir(root string) {
 	r.staticHandler = http.FileServer(http.Dir(root))
 }
@@ -145,6 +223,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ae := req.Header.Get("Accept-Encoding")
 	resp := NewResponseWriter(w, ae, r.CompMinLength)
 	reqLog := NewRequestLog(req)
+	var routeParams map[string]string
 	defer func() {
 		if r.RequestLogger == nil {
 			return
@@ -155,16 +234,17 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		// don't let logging hinder sending response
 		go r.RequestLogger(reqLog)
 	}()
+	defer resp.finish()
 	defer func() {
 		// although the calling code handles panics, we'll do it
 		// here so the RequestLogger can capture it too.
 		if rec := recover(); rec != nil {
-			http.Error(resp, "Internal Server Error", 500)
+			buf := make([]byte, 1<<16)
+			runtime.Stack(buf, true)
+			buf = bytes.Trim(buf, "\x00")
+			r.recovered(resp, req, routeParams, rec, buf)
 			if r.ErrorLogger != nil {
-				buf := make([]byte, 1<<16)
-				runtime.Stack(buf, true)
 				go func() {
-					buf = bytes.Trim(buf, "\x00")
 					err := fmt.Errorf("%v\n%s\n%s\n", rec, reqLog.CommonFormat(), buf)
 					r.ErrorLogger(err)
 				}()
@@ -172,48 +252,100 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		}
 	}()
 
-	resource, ok := r.routes.GetResource(req.URL.Path)
+	sels := r.selections(req)
+	resource, ok := getResource(sels, req.URL.Path)
+	if !ok {
+		if p, found := r.normalizedPath(sels, req.URL.Path); found {
+			if r.PathPolicy.Action == PathRedirect {
+				r.redirectPath(resp, req, p)
+				return
+			}
+			if resource, ok = getResource(sels, p); ok {
+				req = withPath(req, p)
+			}
+		}
+	}
 	if !ok {
 		// try static files
 		if r.staticHandler != nil {
-			r.staticHandler.ServeHTTP(resp, req)
+			r.serveStatic(resp, req)
 			return
 		}
 
-		http.NotFound(resp, req)
+		if r.NotFoundHandler == nil {
+			if mt := problemMediaType(req); mt != "" {
+				writeProblem(resp, NewProblem(http.StatusNotFound, ""), mt)
+				return
+			}
+			http.NotFound(resp, req)
+			return
+		}
+		c := r.newContext(resp, req, nil)
+		defer c.cancel()
+		defer c.removeForm()
+		r.NotFoundHandler(c)
+		r.respond(c, resp)
 		return
 	}
+	routeParams = resource.RouteParams
 
 	if handleCORS(req, resp, resource) {
 		resp.WriteHeader(http.StatusOK)
 		return
 	}
 
-	fn, ok := resource.Handlers[req.Method]
-	if !ok {
+	method := req.Method
+	if method == "HEAD" && r.AutoHandleHead && !resource.hasMethod("HEAD") && resource.hasMethod("GET") {
+		// use the GET handler, but only send the headers
+		method = "GET"
+		resp.headOnly = true
+	}
+	if !resource.hasMethod(method) {
 		if r.AutoPopulateOptionsAllow {
 			// if a dedicated OPTIONS handler hasn't been added to the resource
 			// then just return with ALLOW header.
-			for k := range resource.Handlers {
+			for _, k := range resource.methods() {
 				resp.Header().Add("Allow", k)
 			}
+			if r.AutoHandleHead && resource.hasMethod("GET") && !resource.hasMethod("HEAD") {
+				resp.Header().Add("Allow", "HEAD")
+			}
+		}
+		if r.MethodNotAllowedHandler == nil {
+			if mt := problemMediaType(req); mt != "" {
+				writeProblem(resp, NewProblem(http.StatusMethodNotAllowed, ""), mt)
+				return
+			}
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
 		}
-		resp.WriteHeader(http.StatusMethodNotAllowed)
+		c := r.newContext(resp, req, resource.RouteParams)
+		c.paramConstraints = resource.ParamConstraints
+		c.status = http.StatusMethodNotAllowed
+		defer c.cancel()
+		defer c.removeForm()
+		r.MethodNotAllowedHandler(c)
+		r.respond(c, resp)
 		return
 	}
 
-	c := &Context{
-		Request:        req,
-		Writer:         resp,
-		RouteParams:    resource.RouteParams,
-		encoderEngine:  r.EncoderEngine,
-		modelValidator: r.modelValidator,
+	fn, ok := r.handler(req, resource, method)
+	if !ok {
+		msg := fmt.Sprintf("No version of the resource matches the requested formats: %q", req.Header.Get("Accept"))
+		httpError(resp, req, msg, http.StatusNotAcceptable)
+		return
 	}
+	resource.setDefaults(method)
+
+	c := r.newContext(resp, req, resource.RouteParams)
+	c.paramConstraints = resource.ParamConstraints
+	defer c.cancel()
+	defer c.removeForm()
 
 	//call prehooks
 	for _, h := range r.preHooks {
 		h(c)
-		if resp.responded || c.responseReady {
+		if c.responded() {
 			break
 		}
 	}
@@ -229,33 +361,66 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// handlerName := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
 
 	// only run handler if a prehook hasn't responded already
-	if !resp.responded && !c.responseReady {
+	if !c.responded() {
 		fn.ServeHTTP(c)
+		reqLog.Err = c.err
 	}
 
-	//perform content negotiation...
+	if !r.respond(c, resp) {
+		return
+	}
+
+	resp.readonly = true // prevent PostHooks from altering the response
+	// any group PostHooks are called before the Router PostHooks
+	for _, h := range c.postHooks {
+		h(c)
+	}
+	for _, h := range r.postHooks {
+		h(c)
+	}
+}
+
+// handler returns the handler function of resource for method, selecting
+// from any versions of the handler first. It returns false if there are
+// versions but none is selected by the request, and there is no
+// unversioned handler.
+func (r *Router) handler(req *http.Request, resource *Resource, method string) (ContextHandlerFunc, bool) {
+	if vs := resource.Versions[method]; len(vs) > 0 {
+		if fn, ok := r.selectVersion(req, vs); ok {
+			return fn, true
+		}
+	}
+	fn, ok := resource.Handlers[method]
+	return fn, ok
+}
+
+func (r *Router) newContext(resp *ResponseWriter, req *http.Request, routeParams map[string]string) *Context {
+	return &Context{
+		Request:        req,
+		Writer:         resp,
+		RouteParams:    routeParams,
+		encoderEngine:  r.EncoderEngine,
+		modelValidator: r.modelValidator,
+		router:         r,
+	}
+}
+
+// respond performs content negotiation and writes the response
+// built up in the Context. It returns false if no acceptable
+// encoder could be found for the response model.
+func (r *Router) respond(c *Context, resp *ResponseWriter) bool {
 	var encoder Encoder
 	var ct string
 	var err error
 	if c.model != nil {
 		encoder, ct, err = c.GetEncoder()
 		if err != nil {
-			msg := fmt.Sprintf("Unable to encode to requested acceptable formats: %q", req.Header.Get("Accept"))
-			http.Error(resp, msg, http.StatusNotAcceptable)
-			return
+			msg := fmt.Sprintf("Unable to encode to requested acceptable formats: %q", c.Request.Header.Get("Accept"))
+			httpError(resp, c.Request, msg, http.StatusNotAcceptable)
+			return false
 		}
 		resp.Header().Set("Content-Type", ct)
 	}
-	// if c.Reader != nil {
-	// 	defer c.Reader.Close()
-	// 	_, err = io.Copy(c.Writer, c.Reader)
-	// 	if err != nil {
-	// 		log.Printf("unable to copy stream to context writer", err)
-	// 		msg := fmt.Sprintf("Unable to stream data")
-	// 		r.sendError(w, msg, http.StatusInternalServerError)
-	// 		return
-	// 	}
-	// }
 
 	if c.status != 0 && c.status != 200 {
 		resp.WriteHeader(c.status)
@@ -268,11 +433,35 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	} else {
 		resp.Write(c.payload)
 	}
+	return true
+}
 
-	resp.readonly = true // prevent PostHooks from altering the response
-	for _, h := range r.postHooks {
-		h(c)
+// recovered sends the response following a handler panic, using the
+// PanicHandler if one has been set. Nothing is sent if the response
+// has already been started.
+func (r *Router) recovered(resp *ResponseWriter, req *http.Request, routeParams map[string]string, rec interface{}, stack []byte) {
+	// nothing has been sent for a head only response, so it
+	// can still be replaced
+	if resp.headersSent && !resp.resetHead() {
+		return
+	}
+	if r.PanicHandler == nil {
+		httpError(resp, req, "Internal Server Error", 500)
+		return
 	}
+	defer func() {
+		// the PanicHandler has panicked too, so fall back to
+		// the plain response if possible
+		if recover() != nil && (!resp.headersSent || resp.resetHead()) {
+			httpError(resp, req, "Internal Server Error", 500)
+		}
+	}()
+	c := r.newContext(resp, req, routeParams)
+	c.status = http.StatusInternalServerError
+	c.recovered = rec
+	c.stack = stack
+	r.PanicHandler(c)
+	r.respond(c, resp)
 }
 
 // AddPreHook adds a ContextHandlerFunc that will be called before any
@@ -28,7 +28,11 @@ func (m *mockRoutes) TestValidators(s, constraint string) bool {
 	return v.Validate(s, []string{})
 }
 
-func (m *mockRoutes) AddHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc) {
+func (m *mockRoutes) AddHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc, mw ...Middleware) {
+	m.AddRegistration(pattern, method, registration{handlerFunc: handlerFunc, middleware: mw})
+}
+
+func (m *mockRoutes) AddRegistration(pattern, method string, reg registration) {
 	_, ok := m.routes[pattern]
 	if !ok {
 		m.routes[pattern] = make(map[string]ContextHandlerFunc)
@@ -37,7 +41,11 @@ func (m *mockRoutes) AddHandlerFunc(pattern, method string, handlerFunc ContextH
 	if dup {
 		panic(fmt.Sprintf("duplicate route handler method: \"%s %s\"", method, pattern))
 	}
-	m.routes[pattern][method] = handlerFunc
+	m.routes[pattern][method] = withHooks(chain(reg.handlerFunc, reg.middleware), reg.hooks)
+}
+
+func (m *mockRoutes) AddVersionedHandlerFunc(pattern, method string, vh VersionedHandler) {
+	m.AddRegistration(pattern, method, registration{handlerFunc: vh.Handler, middleware: vh.Middleware, hooks: vh.hooks})
 }
 
 func (m *mockRoutes) GetResource(path string) (*Resource, bool) {
@@ -60,6 +68,26 @@ func (m *mockRoutes) AddCORS(pattern string, config CORSConfig) {
 	m.corsConfigs[pattern] = config
 }
 
+func (m *mockRoutes) Routes() []RouteInfo {
+	var routes routeInfos
+	for p, hm := range m.routes {
+		for k, h := range hm {
+			routes = append(routes, RouteInfo{Pattern: p, Method: k, Handler: extractFnName(h)})
+		}
+	}
+	sort.Sort(routes)
+	return routes
+}
+
+func (m *mockRoutes) CaseInsensitivePath(path string) (string, bool) {
+	for p := range m.routes {
+		if strings.EqualFold(p, path) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
 func newMockRoutes() *mockRoutes {
 	mr := mockRoutes{}
 	mr.routes = make(map[string]map[string]ContextHandlerFunc)
@@ -211,6 +239,206 @@ func TestWhenNoMatchingHandlerServeHTTPReturns405MethodNotAllowed(t *testing.T)
 	}
 }
 
+func TestWhenNoMatchingRouteServeHTTPCallsNotFoundHandler(t *testing.T) {
+	want := `{"error":"no mangoes here"}`
+	rtr := NewRouter()
+	rtr.NotFoundHandler = func(c *Context) {
+		c.RespondWith(map[string]string{"error": "no mangoes here"}).WithStatus(404)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	req.Header.Set("Accept", "application/json")
+	rtr.ServeHTTP(w, req)
+
+	if w.Code != 404 {
+		t.Errorf("Status got %d, want %d", w.Code, 404)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type got %q, want %q", ct, "application/json")
+	}
+	got := strings.TrimSpace(w.Body.String())
+	if got != want {
+		t.Errorf("Body got %q, want %q", got, want)
+	}
+}
+
+func TestWhenNoMatchingHandlerServeHTTPCallsMethodNotAllowedHandler(t *testing.T) {
+	want := "405|DELETE|no mangoes for you"
+	rtr := NewRouter()
+	rtr.Delete("/test", testFunc)
+	rtr.MethodNotAllowedHandler = func(c *Context) {
+		c.RespondWith("no mangoes for you")
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s|%s", w.Code, w.Header().Get("Allow"), w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestWhenHandlerPanicsServeHTTPCallsPanicHandler(t *testing.T) {
+	want := "500|what no mangoes!|true|mango"
+	rtr := NewRouter()
+	rtr.Get("/{fruit}", func(c *Context) {
+		panic("what no mangoes!")
+	})
+	rtr.PanicHandler = func(c *Context) {
+		rec, stack := c.Recovered()
+		msg := fmt.Sprintf("%v|%t|%s", rec, len(stack) > 0, c.RouteParams["fruit"])
+		c.RespondWith(msg)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestWhenPanicHandlerPanicsServeHTTPReturns500(t *testing.T) {
+	want := "500|Internal Server Error\n"
+	rtr := NewRouter()
+	rtr.Get("/mango", func(c *Context) {
+		panic("what no mangoes!")
+	})
+	rtr.PanicHandler = func(c *Context) {
+		panic("still no mangoes!")
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestHEADRequestIsHandledByGETHandlerWhenAutoHandleHead(t *testing.T) {
+	want := "200|ripe|22|"
+	rtr := NewRouter()
+	rtr.AutoHandleHead = true
+	rtr.Get("/mango", func(c *Context) {
+		c.RespondWith("mangoes in the morning").WithHeader("X-Mango", "ripe")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s|%s|%s", w.Code, w.Header().Get("X-Mango"),
+		w.Header().Get("Content-Length"), w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestHEADRequestUsesHEADHandlerWhenAutoHandleHead(t *testing.T) {
+	want := "head"
+	rtr := NewRouter()
+	rtr.AutoHandleHead = true
+	rtr.Get("/mango", func(c *Context) {
+		c.RespondWith("get")
+	})
+	rtr.Head("/mango", func(c *Context) {
+		c.Respond().WithHeader("X-Handler", "head")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Header().Get("X-Handler")
+	if got != want {
+		t.Errorf("Handler got %q, want %q", got, want)
+	}
+}
+
+func TestHEADRequestReturns405WhenNotAutoHandleHead(t *testing.T) {
+	want := 405
+	rtr := NewRouter()
+	rtr.Get("/mango", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestHEADRequestHeadersMatchGETWhenCompressed(t *testing.T) {
+	rtr := NewRouter()
+	rtr.AutoHandleHead = true
+	rtr.CompMinLength = 10
+	rtr.Get("/mango", func(c *Context) {
+		c.RespondWith("mangoes in the morning, mangoes in the evening")
+	})
+
+	get := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rtr.ServeHTTP(get, req)
+	want := fmt.Sprintf("gzip|%d|", get.Body.Len())
+
+	w := httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/mango", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%s|%s|%s", w.Header().Get("Content-Encoding"),
+		w.Header().Get("Content-Length"), w.Body.String())
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestHEADRequestReturns500WhenGETHandlerPanicsAfterWriteHeader(t *testing.T) {
+	want := 500
+	rtr := NewRouter()
+	rtr.AutoHandleHead = true
+	rtr.Get("/mango", func(c *Context) {
+		c.Writer.WriteHeader(200)
+		panic("bad mango")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestAllowIncludesHEADWhenAutoHandleHead(t *testing.T) {
+	want := "GET, HEAD"
+	rtr := NewRouter()
+	rtr.AutoHandleHead = true
+	rtr.Get("/test", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/test", nil)
+	rtr.ServeHTTP(w, req)
+	sort.Strings(w.HeaderMap["Allow"])
+	got := strings.Join(w.HeaderMap["Allow"], ", ")
+	if got != want {
+		t.Errorf("Allow got %q, want %q", got, want)
+	}
+}
+
 func TestWhenNoMatchingHandlerForOPTIONSRequestAndAutoPopulateOptionsAllow(t *testing.T) {
 	want := "DELETE, GET, POST"
 	rtr := Router{}
@@ -0,0 +1,536 @@
+package mango
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema is a JSON Schema (draft 2020-12) describing a model, or a
+// member of a model. Only the keywords needed to express the types of
+// Go values, and the rules of the built in validators, are included.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Contains             *Schema            `json:"contains,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Minimum              json.Number        `json:"minimum,omitempty"`
+	Maximum              json.Number        `json:"maximum,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// JSONSchemaDialect is the URI of the JSON Schema draft which the
+// documents returned by the Router JSONSchema method conform to.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema document describing model m, which
+// expresses the rules of the validate tags of its members in the same
+// way that the Router model validator applies them, so that they can
+// also be applied by clients:
+//
+//	type Fruit struct {
+//		Name   string   `json:"name" validate:"lenrange(2,20)"`
+//		Colour *string  `json:"colour" validate:"notnil,*inset(red,green)"`
+//		Tags   []string `json:"tags" validate:"lenmax(5)"`
+//	}
+//
+// describes a name between 2 and 20 characters, a colour of red or
+// green, and up to 5 tags. Members whose zero value fails their
+// constraints, such as the name and colour above, are required.
+//
+// Nested structs are validated recursively, as are the struct elements
+// of slices and maps, unless the member has the ignorecontents
+// constraint. Constraints prefixed with '*' apply to the value a pointer
+// refers to rather than the pointer itself. Named struct types are
+// described in $defs. Constraints which cannot be expressed in a JSON
+// Schema, such as those of custom validators and the ValidateFuncs added
+// with AddModelValidator, are omitted.
+func (r *Router) JSONSchema(m interface{}) *Schema {
+	t := reflect.TypeOf(m)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	g := newSchemaGenerator(r.ValidationHandler, "#/$defs/")
+	var s *Schema
+	if t.Kind() == reflect.Struct {
+		// the model is described at the root of the document, so
+		// recursive references to it refer to the root
+		g.root = t
+		s = g.objectSchema(t, false)
+	} else {
+		s = g.schema(t, "", false)
+	}
+	s.SchemaURI = JSONSchemaDialect
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s
+}
+
+var (
+	stringType        = reflect.TypeOf("")
+	uuidType          = reflect.TypeOf(UUID{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// constraintPatterns holds the regular expressions equivalent to the
+// built in validators which test strings against a pattern.
+var constraintPatterns = map[string]string{
+	"alpha":                `^[a-zA-Z]+$`,
+	"alphanum":             `^[a-zA-Z0-9]+$`,
+	"digits":               `^\d+$`,
+	"hex":                  `^[0-9a-fA-F]+$`,
+	"hex32":                `^[0-9a-fA-F]+$`,
+	"hex64":                `^[0-9a-fA-F]+$`,
+	"sentence":             `^[a-zA-Z0-9 ,.()'":;!?-]+$`,
+	"phone":                `^(\+[0-9]+)?\s?((\([0-9]+\))|([0-9]+))?\s?[0-9]+\s?[0-9]+$`,
+	"notwhitespace":        `^$|\S`,
+	"notemptyorwhitespace": `\S`,
+}
+
+// integerPattern is the regular expression equivalent to the int32 and
+// int64 validators, which test strings hold integers.
+const integerPattern = `^[+-]?\d+$`
+
+// schemaGenerator generates the Schemas of Go types. Named struct types
+// are held as definitions, which are referenced by refPrefix followed by
+// the type name, so that types used in several places, or recursively,
+// are only described once.
+type schemaGenerator struct {
+	handler   ValidationHandler
+	refPrefix string
+	// root is the type described at the root of the document, if any.
+	root  reflect.Type
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+	// inline holds the struct types being described without their
+	// constraints, to prevent recursive types repeating forever.
+	inline map[reflect.Type]bool
+}
+
+func newSchemaGenerator(handler ValidationHandler, refPrefix string) *schemaGenerator {
+	if handler == nil {
+		handler = newValidationHandler()
+	}
+	return &schemaGenerator{
+		handler:   handler,
+		refPrefix: refPrefix,
+		defs:      make(map[string]*Schema),
+		names:     make(map[reflect.Type]string),
+		inline:    make(map[reflect.Type]bool),
+	}
+}
+
+// schema returns the Schema of type t, restricted by constraints in the
+// same way that the model validator applies them. If plain is true, the
+// validate tags of any struct members are ignored, as they are when a
+// member has the ignorecontents constraint.
+func (g *schemaGenerator) schema(t reflect.Type, constraints string, plain bool) *Schema {
+	tests := g.handler.ParseConstraints(constraints)
+	if _, ok := tests["ignorecontents"]; ok {
+		plain = true
+	}
+	if t.Kind() == reflect.Ptr {
+		// constraints for the dereferenced value are prefixed with '*'
+		var deref []string
+		for name, args := range tests {
+			if strings.HasPrefix(name, "*") {
+				deref = append(deref, joinConstraint(name[1:], args))
+			}
+		}
+		return g.schema(t.Elem(), strings.Join(deref, ","), plain)
+	}
+
+	s := g.typeSchema(t, plain)
+	if s.Ref != "" {
+		// the constraints of structs can only make them required
+		return s
+	}
+	// apply the constraints in a consistent order, so that the same
+	// Schema is generated each time
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		constrain(s, name, tests[name])
+	}
+	return s
+}
+
+// paramSchema returns the Schema of a route parameter with constraints.
+// Unlike model members, parameters are strings which may be constrained
+// to hold integers.
+func (g *schemaGenerator) paramSchema(constraints string) *Schema {
+	s := g.schema(stringType, constraints, false)
+	for name := range g.handler.ParseConstraints(constraints) {
+		if name == "int32" || name == "int64" {
+			s.Type, s.Format = "integer", name
+			if s.Pattern == integerPattern {
+				s.Pattern = ""
+			}
+		}
+	}
+	return s
+}
+
+// typeSchema returns the Schema of type t, without any constraints.
+func (g *schemaGenerator) typeSchema(t reflect.Type, plain bool) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// the encoding is unknown
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Minimum: "0"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// byte slices are encoded as base64 strings
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.elemSchema(t.Elem(), plain)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.elemSchema(t.Elem(), plain)}
+	case reflect.Struct:
+		return g.structSchema(t, plain)
+	}
+	// interfaces, and anything else which can hold any value
+	return &Schema{}
+}
+
+// elemSchema returns the Schema of the elements of a slice, array or map.
+// The model validator only validates elements which are structs, so the
+// constraints of other elements, such as pointers to structs, are ignored.
+func (g *schemaGenerator) elemSchema(t reflect.Type, plain bool) *Schema {
+	return g.schema(t, "", plain || t.Kind() != reflect.Struct)
+}
+
+// structSchema returns the Schema of struct type t. Named types are
+// added to the definitions and a reference to them is returned.
+func (g *schemaGenerator) structSchema(t reflect.Type, plain bool) *Schema {
+	if plain || t.Name() == "" {
+		if g.inline[t] {
+			return &Schema{Type: "object"}
+		}
+		g.inline[t] = true
+		defer delete(g.inline, t)
+		return g.objectSchema(t, plain)
+	}
+	if t == g.root {
+		return &Schema{Ref: "#"}
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = g.defName(t)
+		g.names[t] = name
+		// add the definition before describing the members, in case
+		// they refer to it
+		g.defs[name] = &Schema{}
+		*g.defs[name] = *g.objectSchema(t, false)
+	}
+	return &Schema{Ref: g.refPrefix + name}
+}
+
+// defName returns a definition name for t which is not already in use
+// by another type.
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		// remove the type arguments of generic types
+		name = name[:i]
+	}
+	for i := 2; ; i++ {
+		if _, ok := g.defs[name]; !ok {
+			return name
+		}
+		name = strings.TrimRight(name, "0123456789") + strconv.Itoa(i)
+	}
+}
+
+// objectSchema returns the Schema of the members of struct type t, as
+// they are encoded as JSON. Members which are bound from the route,
+// query, headers or cookies, and have no json tag, are omitted.
+func (g *schemaGenerator) objectSchema(t reflect.Type, plain bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addMembers(s, t, plain, make(map[string]bool))
+	if len(s.Properties) == 0 {
+		s.Properties = nil
+	}
+	return s
+}
+
+// addMembers adds the members of struct type t to s. Members of embedded
+// structs are promoted, unless s already has a member of the same name.
+func (g *schemaGenerator) addMembers(s *Schema, t reflect.Type, plain bool, seen map[string]bool) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || isBindingOnly(field) {
+			continue
+		}
+		name := strings.SplitN(tag, ",", 2)[0]
+		ft := field.Type
+		if field.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		constraints := ""
+		if !plain {
+			constraints = field.Tag.Get("validate")
+		}
+		ps := g.schema(ft, constraints, plain)
+		if strings.Contains(tag, ",string") && ps.Type != "" && ps.Type != "string" {
+			ps = &Schema{Type: "string"}
+		}
+		s.Properties[name] = ps
+		if g.required(ft, constraints) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	for _, et := range embedded {
+		g.addMembers(s, et, plain, seen)
+	}
+}
+
+// required reports whether a member of type t must be present to
+// satisfy constraints, i.e. its zero value fails any of them.
+func (g *schemaGenerator) required(t reflect.Type, constraints string) bool {
+	if constraints == "" || t.Kind() == reflect.Struct {
+		return false
+	}
+	zero := reflect.Zero(t).Interface()
+	for name, args := range g.handler.ParseConstraints(constraints) {
+		// constraints for the dereferenced value are not tested when
+		// a pointer is nil
+		if name == "ignorecontents" || strings.HasPrefix(name, "*") {
+			continue
+		}
+		if !g.satisfies(zero, joinConstraint(name, args)) {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfies reports whether v satisfies constraint. Constraints which
+// cannot validate v, or are unknown, are treated as satisfied.
+func (g *schemaGenerator) satisfies(v interface{}, constraint string) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = true
+		}
+	}()
+	_, ok = g.handler.IsValid(v, constraint)
+	return ok
+}
+
+// constrain restricts s in accordance with the named constraint.
+// Constraints which cannot be expressed as a Schema, including those of
+// custom validators, are ignored.
+func constrain(s *Schema, name string, args []string) {
+	if p, ok := constraintPatterns[name]; ok {
+		addPattern(s, p)
+		return
+	}
+	arg := func(i int) string {
+		if i < len(args) {
+			return strings.TrimSpace(args[i])
+		}
+		return ""
+	}
+	switch name {
+	case "int32", "int64":
+		addPattern(s, integerPattern)
+	case "uuid":
+		s.Format = "uuid"
+	case "email":
+		s.Format = "email"
+	case "regex":
+		addPattern(s, arg(0))
+	case "prefix":
+		addPattern(s, "^"+regexp.QuoteMeta(arg(0)))
+	case "suffix":
+		addPattern(s, regexp.QuoteMeta(arg(0))+"$")
+	case "contains":
+		switch s.Type {
+		case "string":
+			addPattern(s, regexp.QuoteMeta(arg(0)))
+		case "array":
+			s.Contains = &Schema{Const: arg(0)}
+		case "object":
+			s.Required = append(s.Required, arg(0))
+		}
+	case "inset":
+		for i := range args {
+			if s.Type == "integer" {
+				n, err := strconv.ParseInt(arg(i), 10, 64)
+				if err != nil {
+					continue
+				}
+				s.Enum = append(s.Enum, n)
+				continue
+			}
+			s.Enum = append(s.Enum, arg(i))
+		}
+	case "min":
+		s.Minimum = number(arg(0))
+	case "max":
+		s.Maximum = number(arg(0))
+	case "range":
+		s.Minimum = number(arg(0))
+		s.Maximum = number(arg(1))
+	case "notzero":
+		s.Not = &Schema{Const: 0}
+	case "notempty":
+		setLength(s, "1", "")
+	case "lenmin":
+		setLength(s, arg(0), "")
+	case "lenmax":
+		setLength(s, "", arg(0))
+	case "lenrange":
+		setLength(s, arg(0), arg(1))
+	}
+}
+
+// setLength sets the minimum and maximum length of s, in the keywords
+// appropriate to its type. Empty or invalid limits are ignored.
+func setLength(s *Schema, min, max string) {
+	limit := func(v string) *int {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil
+		}
+		return &n
+	}
+	lo, hi := limit(min), limit(max)
+	switch s.Type {
+	case "string":
+		if lo != nil {
+			s.MinLength = lo
+		}
+		if hi != nil {
+			s.MaxLength = hi
+		}
+	case "array":
+		if lo != nil {
+			s.MinItems = lo
+		}
+		if hi != nil {
+			s.MaxItems = hi
+		}
+	case "object":
+		if lo != nil {
+			s.MinProperties = lo
+		}
+		if hi != nil {
+			s.MaxProperties = hi
+		}
+	}
+}
+
+// addPattern adds the regular expression p to s. A Schema can only have
+// one pattern, so any further patterns are added as subschemas.
+func addPattern(s *Schema, p string) {
+	if p == "" {
+		return
+	}
+	if s.Pattern == "" {
+		s.Pattern = p
+		return
+	}
+	s.AllOf = append(s.AllOf, &Schema{Pattern: p})
+}
+
+// number returns v as a json.Number, or an empty json.Number if v is
+// not a valid number.
+func number(v string) json.Number {
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return ""
+	}
+	return json.Number(v)
+}
+
+// joinConstraint returns the constraint name with its args, in the
+// format used in validate tags, e.g. range(1,5).
+func joinConstraint(name string, args []string) string {
+	if len(args) > 0 {
+		name += "(" + strings.Join(args, ",") + ")"
+	}
+	return name
+}
+
+// isBindingOnly reports whether field is bound from the route, query,
+// headers or cookies, rather than the request body.
+func isBindingOnly(field reflect.StructField) bool {
+	if _, ok := field.Tag.Lookup("json"); ok {
+		return false
+	}
+	for _, src := range bindingSources {
+		if name := field.Tag.Get(src); name != "" && name != "-" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package mango
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	Street   string `json:"street" validate:"notempty"`
+	Postcode string `json:"postcode" validate:"regex(^[A-Z0-9 ]+$)"`
+}
+
+type schemaBase struct {
+	Created time.Time `json:"created"`
+}
+
+type schemaModel struct {
+	schemaBase
+	ID       int64             `route:"id"`
+	Name     string            `json:"name" validate:"lenrange(2,20),alpha"`
+	Email    *string           `json:"email,omitempty" validate:"notnil,*email"`
+	Age      int32             `json:"age" validate:"range(18,99)"`
+	Size     uint8             `json:"size"`
+	Kind     string            `json:"kind" validate:"inset(big, small)"`
+	Tags     []string          `json:"tags" validate:"lenmax(3),contains(ripe)"`
+	Scores   map[string]int    `json:"scores" validate:"notempty"`
+	Home     schemaAddress     `json:"home"`
+	Previous []*schemaAddress  `json:"previous"`
+	Loose    schemaAddress     `json:"loose" validate:"ignorecontents"`
+	Extra    interface{}       `json:"extra"`
+	Data     []byte            `json:"data"`
+	Ref      UUID              `json:"ref"`
+	Hidden   string            `json:"-"`
+	Count    int               `json:"count,string"`
+	Raw      json.RawMessage   `json:"raw"`
+	Meta     map[string]string `json:"meta" validate:"lenrange(1,4)"`
+	secret   string
+}
+
+func TestSchemaGeneratorDescribesModels(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"schemaModel", `{"type":"object","properties":{` +
+			`"age":{"type":"integer","format":"int32","minimum":18,"maximum":99},` +
+			`"count":{"type":"string"},` +
+			`"created":{"type":"string","format":"date-time"},` +
+			`"data":{"type":"string","format":"byte"},` +
+			`"email":{"type":"string","format":"email"},` +
+			`"extra":{},` +
+			`"home":{"$ref":"#/defs/schemaAddress"},` +
+			`"kind":{"type":"string","enum":["big","small"]},` +
+			`"loose":{"type":"object","properties":{"postcode":{"type":"string"},"street":{"type":"string"}}},` +
+			`"meta":{"type":"object","additionalProperties":{"type":"string"},"minProperties":1,"maxProperties":4},` +
+			`"name":{"type":"string","pattern":"^[a-zA-Z]+$","minLength":2,"maxLength":20},` +
+			`"previous":{"type":"array","items":{"type":"object","properties":{"postcode":{"type":"string"},"street":{"type":"string"}}}},` +
+			`"raw":{},` +
+			`"ref":{"type":"string","format":"uuid"},` +
+			`"scores":{"type":"object","additionalProperties":{"type":"integer"},"minProperties":1},` +
+			`"size":{"type":"integer","minimum":0},` +
+			`"tags":{"type":"array","items":{"type":"string"},"contains":{"const":"ripe"},"maxItems":3}},` +
+			`"required":["name","email","age","kind","tags","scores","meta"]}`},
+		{"schemaAddress", `{"type":"object","properties":{` +
+			`"postcode":{"type":"string","pattern":"^[A-Z0-9 ]+$"},` +
+			`"street":{"type":"string","minLength":1}},` +
+			`"required":["street","postcode"]}`},
+	}
+	g := newSchemaGenerator(nil, "#/defs/")
+	if got := g.schema(reflect.TypeOf(schemaModel{}), "", false); got.Ref != "#/defs/schemaModel" {
+		t.Errorf("Ref = %q, want %q", got.Ref, "#/defs/schemaModel")
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(g.defs[test.name])
+		if err != nil {
+			t.Fatalf("Error = %q, want nil", err)
+		}
+		if got := string(b); got != test.want {
+			t.Errorf("%s: Schema = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSchemaGeneratorAppliesConstraints(t *testing.T) {
+	tests := []struct {
+		v           interface{}
+		constraints string
+		want        string
+	}{
+		{"", "", `{"type":"string"}`},
+		{"", "prefix(a.b),suffix(z)", `{"type":"string","allOf":[{"pattern":"z$"}],"pattern":"^a\\.b"}`},
+		{"", "notwhitespace", `{"type":"string","pattern":"^$|\\S"}`},
+		{"", "int32", `{"type":"string","pattern":"^[+-]?\\d+$"}`},
+		{"", "contains(a+b)", `{"type":"string","pattern":"a\\+b"}`},
+		{"", "lenmin(3)", `{"type":"string","minLength":3}`},
+		{"", "mycustom(3)", `{"type":"string"}`},
+		{0, "notzero", `{"type":"integer","not":{"const":0}}`},
+		{0, "min(3),max(x)", `{"type":"integer","minimum":3}`},
+		{0, "inset(1,2,x)", `{"type":"integer","enum":[1,2]}`},
+		{1.5, "range(0.5,9.5)", `{"type":"number","format":"double","minimum":0.5,"maximum":9.5}`},
+		{[]int{}, "notempty", `{"type":"array","items":{"type":"integer"},"minItems":1}`},
+		{[2]bool{}, "lenrange(1,2)", `{"type":"array","items":{"type":"boolean"},"minItems":1,"maxItems":2}`},
+		{map[string]bool{}, "contains(ripe)", `{"type":"object","required":["ripe"],"additionalProperties":{"type":"boolean"}}`},
+		{new(string), "notnil,*lenmax(5)", `{"type":"string","maxLength":5}`},
+		{new(string), "lenmax(5)", `{"type":"string"}`},
+		{struct {
+			A string `json:"a" validate:"alpha"`
+		}{}, "", `{"type":"object","properties":{"a":{"type":"string","pattern":"^[a-zA-Z]+$"}},"required":["a"]}`},
+	}
+	for _, test := range tests {
+		g := newSchemaGenerator(nil, "#/defs/")
+		b, _ := json.Marshal(g.schema(reflect.TypeOf(test.v), test.constraints, false))
+		if got := string(b); got != test.want {
+			t.Errorf("%T %q: Schema = %s, want %s", test.v, test.constraints, got, test.want)
+		}
+	}
+}
+
+func TestSchemaGeneratorParamSchema(t *testing.T) {
+	tests := []struct {
+		constraints string
+		want        string
+	}{
+		{"", `{"type":"string"}`},
+		{"int32", `{"type":"integer","format":"int32"}`},
+		{"int64", `{"type":"integer","format":"int64"}`},
+		{"uuid", `{"type":"string","format":"uuid"}`},
+		{"hex32", `{"type":"string","pattern":"^[0-9a-fA-F]+$"}`},
+		{"alpha,lenmax(8)", `{"type":"string","pattern":"^[a-zA-Z]+$","maxLength":8}`},
+	}
+	g := newSchemaGenerator(nil, "#/defs/")
+	for _, test := range tests {
+		b, _ := json.Marshal(g.paramSchema(test.constraints))
+		if got := string(b); got != test.want {
+			t.Errorf("%q: Schema = %s, want %s", test.constraints, got, test.want)
+		}
+	}
+}
+
+func TestSchemaGeneratorNamesDefinitionsUniquely(t *testing.T) {
+	type schemaAddress struct {
+		Line string `json:"line"`
+	}
+	g := newSchemaGenerator(nil, "#/defs/")
+	a := g.schema(reflect.TypeOf(schemaModel{}.Home), "", false)
+	b := g.schema(reflect.TypeOf(schemaAddress{}), "", false)
+	c := g.schema(reflect.TypeOf(&schemaAddress{}), "", false)
+	if a.Ref != "#/defs/schemaAddress" {
+		t.Errorf("Ref = %q, want %q", a.Ref, "#/defs/schemaAddress")
+	}
+	if b.Ref != "#/defs/schemaAddress2" {
+		t.Errorf("Ref = %q, want %q", b.Ref, "#/defs/schemaAddress2")
+	}
+	if c.Ref != b.Ref {
+		t.Errorf("Ref = %q, want %q", c.Ref, b.Ref)
+	}
+}
+
+type schemaTree struct {
+	Name     string                   `json:"name" validate:"notempty"`
+	Parent   *schemaTree              `json:"parent"`
+	Children []schemaTree             `json:"children" validate:"lenmax(2)"`
+	Homes    map[string]schemaAddress `json:"homes"`
+}
+
+func TestRouterJSONSchema(t *testing.T) {
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"children":{"type":"array","items":{"$ref":"#"},"maxItems":2},` +
+		`"homes":{"type":"object","additionalProperties":{"$ref":"#/$defs/schemaAddress"}},` +
+		`"name":{"type":"string","minLength":1},` +
+		`"parent":{"$ref":"#"}},` +
+		`"required":["name"],` +
+		`"$defs":{"schemaAddress":{"type":"object","properties":{` +
+		`"postcode":{"type":"string","pattern":"^[A-Z0-9 ]+$"},` +
+		`"street":{"type":"string","minLength":1}},` +
+		`"required":["street","postcode"]}}}`
+	r := NewRouter()
+	for _, m := range []interface{}{schemaTree{}, &schemaTree{}} {
+		b, err := json.Marshal(r.JSONSchema(m))
+		if err != nil {
+			t.Fatalf("Error = %q, want nil", err)
+		}
+		if got := string(b); got != want {
+			t.Errorf("%T: Schema = %s, want %s", m, got, want)
+		}
+	}
+}
+
+func TestRouterJSONSchemaOfNonStructModel(t *testing.T) {
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"array","items":{"$ref":"#/$defs/schemaAddress"},` +
+		`"$defs":{"schemaAddress":{"type":"object","properties":{` +
+		`"postcode":{"type":"string","pattern":"^[A-Z0-9 ]+$"},` +
+		`"street":{"type":"string","minLength":1}},` +
+		`"required":["street","postcode"]}}}`
+	r := NewRouter()
+	b, _ := json.Marshal(r.JSONSchema([]schemaAddress{}))
+	if got := string(b); got != want {
+		t.Errorf("Schema = %s, want %s", got, want)
+	}
+}
+
+func TestRouterJSONSchemaUsesCustomValidators(t *testing.T) {
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{"code":{"type":"string"}},"required":["code"]}`
+	r := NewRouter()
+	r.AddValidator(&schemaCodeValidator{})
+	b, _ := json.Marshal(r.JSONSchema(struct {
+		Code string `json:"code" validate:"fruitcode"`
+	}{}))
+	if got := string(b); got != want {
+		t.Errorf("Schema = %s, want %s", got, want)
+	}
+}
+
+type schemaCodeValidator struct{}
+
+func (v *schemaCodeValidator) Validate(val interface{}, args []string) bool {
+	return strings.HasPrefix(val.(string), "F")
+}
+
+func (v *schemaCodeValidator) Type() string {
+	return "fruitcode"
+}
+
+func (v *schemaCodeValidator) FailureMsg() string {
+	return "must be a fruit code."
+}
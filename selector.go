@@ -0,0 +1,242 @@
+package mango
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// selector holds the routes which are only matched by requests for a
+// particular host and/or with particular header values.
+type selector struct {
+	host      string
+	hostParts []string
+	hostPort  bool
+	headers   map[string]string
+	routes    routes
+	// constraints holds the constraints of the host parameters.
+	constraints map[string]string
+}
+
+// selection is a set of routes matching a request, together with any
+// parameter values captured from the request host and their constraints.
+type selection struct {
+	routes      routes
+	params      map[string]string
+	constraints map[string]string
+}
+
+// Host returns a new Group whose routes will only be matched by requests
+// for hosts matching pattern. Host patterns can contain parameters for
+// whole labels, e.g. "{tenant}.example.com", and the values are added,
+// in lower case, to the Context RouteParams. Parameters can have
+// constraints in the same way as route pattern parameters.
+// Hosts are matched ignoring case, and any port in the request host is
+// ignored unless pattern contains a port.
+// Requests for a matching host which don't match any of the host routes
+// will be matched against the routes registered directly with the Router.
+// Host panics if pattern is malformed.
+func (r *Router) Host(pattern string) *Group {
+	return r.selectorGroup(pattern, nil)
+}
+
+// Header returns a new Group whose routes will only be matched by requests
+// with a key header having value. Values are case sensitive.
+// Requests with a matching header which don't match any of the Group
+// routes will be matched against the routes registered directly with the
+// Router.
+func (r *Router) Header(key, value string) *Group {
+	return r.selectorGroup("", map[string]string{http.CanonicalHeaderKey(key): value})
+}
+
+// HostHeader returns a new Group whose routes will only be matched by
+// requests for hosts matching pattern and with the headers values in
+// headers. See Host and Header for more details.
+func (r *Router) HostHeader(pattern string, headers map[string]string) *Group {
+	h := make(map[string]string)
+	for k, v := range headers {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+	return r.selectorGroup(pattern, h)
+}
+
+func (r *Router) selectorGroup(host string, headers map[string]string) *Group {
+	sel := r.selector(host, headers)
+	return &Group{
+		router:   r,
+		selector: sel,
+	}
+}
+
+// selector returns the selector for host and headers, creating a new
+// one if required.
+func (r *Router) selector(host string, headers map[string]string) *selector {
+	for _, s := range r.selectors {
+		if s.host == host && sameHeaders(s.headers, headers) {
+			return s
+		}
+	}
+	t := newTree(r.ValidationHandler)
+	t.GlobalCORS = r.globalCORS
+	s := &selector{
+		host:      host,
+		hostParts: splitHost(host),
+		headers:   headers,
+		routes:    t,
+	}
+	if n := len(s.hostParts); n > 0 {
+		last := s.hostParts[n-1]
+		s.hostPort = last[0] != '{' && strings.Contains(last, ":")
+	}
+	s.constraints = patternConstraints(host)
+	r.selectors = append(r.selectors, s)
+	return s
+}
+
+// selections returns the routes which could match req, in the order in
+// which they should be searched. The Router routes are always last.
+func (r *Router) selections(req *http.Request) []selection {
+	var sels []selection
+	for _, s := range r.selectors {
+		if params, ok := s.match(req, r.ValidationHandler); ok {
+			sels = append(sels, selection{routes: s.routes, params: params, constraints: s.constraints})
+		}
+	}
+	return append(sels, selection{routes: r.routes})
+}
+
+// getResource returns the first resource in sels matching path, with the
+// host parameter values added to its RouteParams, and their constraints
+// to its ParamConstraints.
+func getResource(sels []selection, path string) (*Resource, bool) {
+	for _, s := range sels {
+		res, ok := s.routes.GetResource(path)
+		if !ok {
+			continue
+		}
+		for k, v := range s.params {
+			if res.RouteParams == nil {
+				res.RouteParams = make(map[string]string)
+			}
+			res.RouteParams[k] = v
+		}
+		if len(s.constraints) > 0 {
+			pc := make(map[string]string)
+			for k, v := range res.ParamConstraints {
+				pc[k] = v
+			}
+			for k, v := range s.constraints {
+				pc[k] = v
+			}
+			res.ParamConstraints = pc
+		}
+		return res, true
+	}
+	return nil, false
+}
+
+// match returns true if req is for the host and has the header values of
+// the selector. The host parameter values are also returned.
+func (s *selector) match(req *http.Request, v ValidationHandler) (map[string]string, bool) {
+	for k, val := range s.headers {
+		if req.Header.Get(k) != val {
+			return nil, false
+		}
+	}
+	if s.host == "" {
+		return nil, true
+	}
+	host := strings.ToLower(req.Host)
+	if !s.hostPort {
+		host = stripPort(host)
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) != len(s.hostParts) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, p := range s.hostParts {
+		if p[0] != '{' {
+			if !strings.EqualFold(p, labels[i]) {
+				return nil, false
+			}
+			continue
+		}
+		name, constraint, _ := parseParam(p[1 : len(p)-1])
+		if labels[i] == "" {
+			return nil, false
+		}
+		if constraint != "" {
+			if _, ok := v.IsValid(labels[i], constraint); !ok {
+				return nil, false
+			}
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[name] = labels[i]
+	}
+	return params, true
+}
+
+// splitHost splits a host pattern into its labels. Parameters must
+// occupy a whole label, but their constraints can contain dots.
+// splitHost panics if the pattern is malformed.
+func splitHost(pattern string) []string {
+	if pattern == "" {
+		return nil
+	}
+	var parts []string
+	for {
+		if strings.HasPrefix(pattern, "{") {
+			i := paramEnd(pattern[1:])
+			if i < 0 {
+				panic("invalid host syntax: " + pattern)
+			}
+			parts = append(parts, pattern[:i+2])
+			pattern = pattern[i+2:]
+			if pattern == "" {
+				return parts
+			}
+			if pattern[0] != '.' {
+				panic(fmt.Sprintf("invalid host syntax: parameters must occupy a whole label: %s", pattern))
+			}
+			pattern = pattern[1:]
+			continue
+		}
+		i := strings.IndexByte(pattern, '.')
+		if i < 0 {
+			i = len(pattern)
+		}
+		label := pattern[:i]
+		if strings.ContainsAny(label, "{}") {
+			panic(fmt.Sprintf("invalid host syntax: parameters must occupy a whole label: %s", label))
+		}
+		parts = append(parts, label)
+		if i == len(pattern) {
+			return parts
+		}
+		pattern = pattern[i+1:]
+	}
+}
+
+// stripPort removes any port from host.
+func stripPort(host string) string {
+	i := strings.LastIndexByte(host, ':')
+	if i < 0 || strings.IndexByte(host[i:], ']') >= 0 {
+		return host
+	}
+	return host[:i]
+}
+
+func sameHeaders(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
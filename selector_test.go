@@ -0,0 +1,204 @@
+package mango
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRoutesOnlyMatchHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"api.example.com", "api"},
+		{"API.Example.com", "api"},
+		{"api.example.com:8080", "api"},
+		{"www.example.com", "default"},
+		{"acme.api.example.com", "default"},
+	}
+	rtr := NewRouter()
+	rtr.Host("api.example.com").Get("/mango", func(c *Context) {
+		c.RespondWith("api")
+	})
+	rtr.Get("/mango", func(c *Context) {
+		c.RespondWith("default")
+	})
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/mango", nil)
+		req.Host = test.host
+		rtr.ServeHTTP(w, req)
+
+		got := w.Body.String()
+		if got != test.want {
+			t.Errorf("Handler for %q got %q, want %q", test.host, got, test.want)
+		}
+	}
+}
+
+func TestHostParametersAreAddedToRouteParams(t *testing.T) {
+	want := "acme|42"
+	rtr := NewRouter()
+	rtr.Host("{tenant}.example.com").Get("/users/{id}", func(c *Context) {
+		c.RespondWith(c.RouteParams["tenant"] + "|" + c.RouteParams["id"])
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://acme.example.com/users/42", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Body.String()
+	if got != want {
+		t.Errorf("Params got %q, want %q", got, want)
+	}
+}
+
+func TestHostParametersRespectConstraints(t *testing.T) {
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"acme.example.com", 200},
+		{"acme1.example.com", 404},
+		{"example.com", 404},
+	}
+	rtr := NewRouter()
+	rtr.Host("{tenant:alpha}.example.com").Get("/mango", testFunc)
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/mango", nil)
+		req.Host = test.host
+		rtr.ServeHTTP(w, req)
+
+		got := w.Code
+		if got != test.want {
+			t.Errorf("Status for %q got %d, want %d", test.host, got, test.want)
+		}
+	}
+}
+
+func TestHostWithPortOnlyMatchesPort(t *testing.T) {
+	want := "200|404"
+	rtr := NewRouter()
+	rtr.Host("localhost:8080").Get("/mango", testFunc)
+
+	got := ""
+	for _, host := range []string{"localhost:8080", "localhost:9090"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/mango", nil)
+		req.Host = host
+		rtr.ServeHTTP(w, req)
+		got += fmt.Sprintf("|%d", w.Code)
+	}
+	got = got[1:]
+	if got != want {
+		t.Errorf("Status got %q, want %q", got, want)
+	}
+}
+
+func TestHeaderRoutesOnlyMatchHeaderValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"acme", "acme"},
+		{"Acme", "default"},
+		{"", "default"},
+	}
+	rtr := NewRouter()
+	rtr.Header("x-tenant", "acme").Get("/mango", func(c *Context) {
+		c.RespondWith("acme")
+	})
+	rtr.Get("/mango", func(c *Context) {
+		c.RespondWith("default")
+	})
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/mango", nil)
+		if test.value != "" {
+			req.Header.Set("X-Tenant", test.value)
+		}
+		rtr.ServeHTTP(w, req)
+
+		got := w.Body.String()
+		if got != test.want {
+			t.Errorf("Handler for %q got %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestHostRoutesReturn405ForUnhandledMethod(t *testing.T) {
+	want := "405|GET"
+	rtr := NewRouter()
+	rtr.Host("api.example.com").Get("/mango", testFunc)
+	rtr.Post("/mango", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "http://api.example.com/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Header().Get("Allow"))
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestHostGroupsShareRoutes(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	rtr := NewRouter()
+	rtr.Host("api.example.com").Get("/mango", testFunc)
+	rtr.Host("api.example.com").Get("/mango", testFunc)
+}
+
+func TestHostRoutesUseHostCORS(t *testing.T) {
+	want := "http://somewhere.com"
+	rtr := NewRouter()
+	rtr.SetGlobalCORS(CORSConfig{Origins: []string{want}})
+	rtr.Host("api.example.com").Get("/mango", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://api.example.com/mango", nil)
+	req.Header.Set("Origin", want)
+	rtr.ServeHTTP(w, req)
+
+	got := w.Header().Get("Access-Control-Allow-Origin")
+	if got != want {
+		t.Errorf("Allow Origin got %q, want %q", got, want)
+	}
+}
+
+func TestRouterRoutesIncludesHostRoutes(t *testing.T) {
+	want := "/mango||/mango|{tenant}.example.com|/mango||X-Tenant:acme"
+	rtr := NewRouter()
+	rtr.Get("/mango", testFunc)
+	rtr.Host("{tenant}.example.com").Get("/mango", testFunc)
+	rtr.Header("x-tenant", "acme").Get("/mango", testFunc)
+
+	got := ""
+	for _, ri := range rtr.Routes() {
+		got += fmt.Sprintf("|%s|%s", ri.Pattern, ri.Host)
+		for k, v := range ri.Headers {
+			got += "|" + k + ":" + v
+		}
+	}
+	got = got[1:]
+	if got != want {
+		t.Errorf("Routes got %q, want %q", got, want)
+	}
+}
+
+func TestHostPanicsWhenParameterNotWholeLabel(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	rtr := NewRouter()
+	rtr.Host("api-{tenant}.example.com")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -40,8 +41,9 @@ func (t *tree) SetGlobalCORS(config CORSConfig) {
 // the resource matching the pattern.
 // These settings override any global settings.
 func (t *tree) SetCORS(pattern string, config CORSConfig) {
-	node, _ := t.Root().addNode(pattern)
-	node.CORSConfig = &config
+	for _, pn := range t.addPattern(pattern) {
+		pn.node.CORSConfig = &config
+	}
 }
 
 // AddCORS sets the CORS configuration that will be used for
@@ -53,35 +55,239 @@ func (t *tree) AddCORS(pattern string, config CORSConfig) {
 	if t.GlobalCORS == nil {
 		panic("GlobalCORS has not been set")
 	}
-	node, _ := t.Root().addNode(pattern)
 	c := t.GlobalCORS.clone()
 	c.merge(config)
-	node.CORSConfig = c
+	for _, pn := range t.addPattern(pattern) {
+		pn.node.CORSConfig = c
+	}
 }
 
 // AddHandlerFunc adds a new handlerFunc for the supplied pattern and method.
+// The handlerFunc is wrapped in any middleware supplied, the first being
+// outermost.
+// If the pattern ends with optional parameters, the handlerFunc is also
+// added for each of the shorter patterns formed by omitting them.
 // If a handlerFunc already exists for the pattern-method combination,
 // AddHandlerFunc panics.
-func (t *tree) AddHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc) {
-	node, pNames := t.Root().addNode(pattern)
+func (t *tree) AddHandlerFunc(pattern, method string, handlerFunc ContextHandlerFunc, mw ...Middleware) {
+	t.AddRegistration(pattern, method, registration{handlerFunc: handlerFunc, middleware: mw})
+}
 
-	if node.handlers == nil {
-		node.handlers = make(map[string]ContextHandlerFunc)
-		node.paramNames = pNames
-	} else if _, e := node.handlers[method]; e {
-		panic(fmt.Sprintf("duplicate route handler method: \"%s %s\"", method, pattern))
+// AddRegistration adds the handler function of reg for the supplied
+// pattern and method in the same way as AddHandlerFunc, but also wraps
+// the handler function and its middleware in the group hooks of reg, if
+// not nil. The hooks are not listed with the middleware of the route.
+func (t *tree) AddRegistration(pattern, method string, reg registration) {
+	pns := t.addPattern(pattern)
+	for _, pn := range pns {
+		node := pn.node
+		if node.handlers == nil {
+			node.handlers = make(map[string]ContextHandlerFunc)
+			node.paramNames = pn.paramNames
+			node.paramConstraints = pn.constraints
+		} else if _, e := node.handlers[method]; e {
+			panic(fmt.Sprintf("duplicate route handler method: \"%s %s\"", method, pn.pattern))
+		}
+		node.handlers[method] = withHooks(chain(reg.handlerFunc, reg.middleware), reg.hooks)
+		node.setDefaults(method, pn.defaults)
+		if node.registered == nil {
+			node.registered = make(map[string]registration)
+		}
+		node.registered[method] = reg
+	}
+	t.compileConstraints(pns[0].pattern)
+}
+
+// patternNode is a node added for a pattern.
+type patternNode struct {
+	node        *treenode
+	paramNames  *stringList
+	constraints map[string]string
+	defaults    map[string]string
+	pattern     string
+}
+
+// addPattern adds the nodes for pattern, and for each of the shorter
+// patterns formed by omitting any optional parameters at its end. The
+// end node of each pattern is returned, the full pattern first, with
+// the default values of its omitted parameters.
+func (t *tree) addPattern(pattern string) []patternNode {
+	var pns []patternNode
+	for _, e := range expandOptional(pattern) {
+		node, paramNames := t.Root().addNode(e.pattern)
+		constraints := patternConstraints(e.pattern)
+		var defaults map[string]string
+		for _, d := range e.defaults {
+			if t.validators != nil {
+				if _, ok := t.validators.IsValid(d.value, d.constraint); !ok {
+					panic(fmt.Sprintf("invalid default value for route parameter %q: %q", d.name, d.value))
+				}
+			}
+			if defaults == nil {
+				defaults = make(map[string]string)
+			}
+			defaults[d.name] = d.value
+			if d.constraint != "" {
+				constraints[d.name] = d.constraint
+			}
+		}
+		pns = append(pns, patternNode{node, paramNames, constraints, defaults, e.pattern})
+	}
+	return pns
+}
+
+// setDefaults sets the default values of the omitted optional parameters
+// of the handlers for method. Versions of a handler share their defaults,
+// so setDefaults panics if a different value has already been set.
+func (n *treenode) setDefaults(method string, defaults map[string]string) {
+	for k, v := range defaults {
+		if d, ok := n.defaults[method][k]; ok && d != v {
+			panic(fmt.Sprintf("conflicting default values for route parameter %q: %q, %q", k, d, v))
+		}
+		if n.defaults == nil {
+			n.defaults = make(map[string]map[string]string)
+		}
+		if n.defaults[method] == nil {
+			n.defaults[method] = make(map[string]string)
+		}
+		n.defaults[method][k] = v
+	}
+}
+
+// registration holds a handler function, as registered, its middleware
+// and any group hooks. If name is set, it is reported as the name of the
+// handler function, e.g. for the adapters of http.Handlers.
+type registration struct {
+	handlerFunc ContextHandlerFunc
+	middleware  []Middleware
+	hooks       Middleware
+	name        string
+}
+
+// handlerName returns the name of the handler function.
+func (r registration) handlerName() string {
+	if r.name != "" {
+		return r.name
+	}
+	return extractFnName(r.handlerFunc)
+}
+
+// names returns the names of the middleware and handler function, in
+// the order they are called.
+func (r registration) names() []string {
+	return append(middlewareNames(r.middleware), r.handlerName())
+}
+
+// AddVersionedHandlerFunc adds a handler function for pattern and method
+// which is selected by the request media type or version header, rather
+// than by method alone.
+// If a handler already exists for the same pattern, method and either
+// version or media type, AddVersionedHandlerFunc panics. Empty versions
+// and media types are not compared.
+func (t *tree) AddVersionedHandlerFunc(pattern, method string, vh VersionedHandler) {
+	vh.raw = vh.Handler
+	vh.Handler = withHooks(chain(vh.Handler, vh.Middleware), vh.hooks)
+	pns := t.addPattern(pattern)
+	for _, pn := range pns {
+		node := pn.node
+		if node.handlers == nil {
+			node.handlers = make(map[string]ContextHandlerFunc)
+			node.paramNames = pn.paramNames
+			node.paramConstraints = pn.constraints
+		}
+		for _, v := range node.versions[method] {
+			if (v.Version != "" && v.Version == vh.Version) ||
+				(v.MediaType != "" && v.MediaType == vh.MediaType) {
+				panic(fmt.Sprintf("duplicate route handler version: \"%s %s\" (%s %s)",
+					method, pn.pattern, vh.Version, vh.MediaType))
+			}
+		}
+		if node.versions == nil {
+			node.versions = make(map[string][]VersionedHandler)
+		}
+		node.versions[method] = append(node.versions[method], vh)
+		node.setDefaults(method, pn.defaults)
+	}
+	t.compileConstraints(pns[0].pattern)
+}
+
+// constraintCompiler is implemented by ValidationHandlers which can
+// check and prepare constraints ahead of use.
+type constraintCompiler interface {
+	compile(constraints string) error
+}
+
+// compileConstraints prepares the parameter constraints of pattern, so
+// invalid constraints, such as a malformed regular expression, are
+// reported when the route is registered.
+func (t *tree) compileConstraints(pattern string) {
+	cc, ok := t.validators.(constraintCompiler)
+	if !ok {
+		return
+	}
+	for {
+		i := strings.IndexByte(pattern, byte('{'))
+		if i < 0 {
+			return
+		}
+		pattern = pattern[i+1:]
+		i = paramEnd(pattern)
+		_, constraint, _ := parseParam(pattern[:i])
+		if err := cc.compile(constraint); err != nil {
+			panic(fmt.Sprintf("invalid route constraint: %q: %v", constraint, err))
+		}
+		pattern = pattern[i+1:]
 	}
-	node.handlers[method] = handlerFunc
 }
 
 // Resource is a container holding the Handler functions for
 // the various HTTP methods, a RouteParams map of values obtained
-// from the request path and a CORS configuration.
+// from the request path, the constraints those values satisfied
+// and a CORS configuration.
+// Versions holds any handler functions for the various HTTP methods
+// which are selected by the request media type or version header.
 // The CORS config may be nil.
 type Resource struct {
-	Handlers    map[string]ContextHandlerFunc
-	RouteParams map[string]string
-	CORSConfig  *CORSConfig
+	Handlers         map[string]ContextHandlerFunc
+	Versions         map[string][]VersionedHandler
+	RouteParams      map[string]string
+	ParamConstraints map[string]string
+	CORSConfig       *CORSConfig
+	// defaults holds the default values of omitted optional parameters,
+	// by method.
+	defaults map[string]map[string]string
+}
+
+// setDefaults adds the default values of any omitted optional parameters
+// of the handlers for method to the RouteParams.
+func (r *Resource) setDefaults(method string) {
+	for k, v := range r.defaults[method] {
+		if _, ok := r.RouteParams[k]; !ok {
+			r.RouteParams[k] = v
+		}
+	}
+}
+
+// hasMethod returns true if the resource has a handler for method.
+func (r *Resource) hasMethod(method string) bool {
+	if _, ok := r.Handlers[method]; ok {
+		return true
+	}
+	return len(r.Versions[method]) > 0
+}
+
+// methods returns the methods for which the resource has handlers.
+func (r *Resource) methods() []string {
+	var m []string
+	for k := range r.Handlers {
+		m = append(m, k)
+	}
+	for k := range r.Versions {
+		if _, ok := r.Handlers[k]; !ok {
+			m = append(m, k)
+		}
+	}
+	return m
 }
 
 // GetResource traverses the tree looking for a leaf nodes which match the supplied path.
@@ -89,7 +295,7 @@ type Resource struct {
 // If the leaf node journey involves parameter nodes, then associated values
 // will be extracted from the path and added to the resource RouteParams map.
 func (t *tree) GetResource(path string) (*Resource, bool) {
-	n, pValues, ok := t.search(t.Root().children, path)
+	n, pValues, ok := t.search(t.Root().children, path, nil)
 	if !ok {
 		return nil, false
 	}
@@ -100,7 +306,10 @@ func (t *tree) GetResource(path string) (*Resource, bool) {
 			res.RouteParams[n] = pValues.items[i]
 		}
 	}
+	res.defaults = n.defaults
+	res.ParamConstraints = n.paramConstraints
 	res.Handlers = n.handlers
+	res.Versions = n.versions
 	res.CORSConfig = n.CORSConfig
 	if res.CORSConfig == nil {
 		res.CORSConfig = t.GlobalCORS
@@ -108,43 +317,194 @@ func (t *tree) GetResource(path string) (*Resource, bool) {
 	return &res, true
 }
 
-func (t *tree) search(nodes []*treenode, path string) (*treenode, *stringList, bool) {
-	i := strings.IndexByte(path, byte('/'))
+// CaseInsensitivePath looks for a route matching path, ignoring the case
+// of the static parts of the route patterns. If found, the path is
+// returned with the static parts in the case they were registered, so it
+// can be used with GetResource. Parameter values are left unchanged.
+func (t *tree) CaseInsensitivePath(path string) (string, bool) {
+	buf := make([]byte, len(path))
+	copy(buf, path)
+	if _, _, ok := t.search(t.Root().children, path, buf); !ok {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// search looks for a node, amongst nodes or their descendants, which holds
+// handlers and matches path. Sibling nodes are held in priority order
+// (static, constrained param, unconstrained param, catch-all) and are
+// tried in turn, so if a deeper match fails the search backtracks and
+// continues with the next sibling.
+// If buf is not nil, static nodes are matched ignoring case, and the
+// labels of the matching nodes are copied into buf, which must hold the
+// full path being searched.
+func (t *tree) search(nodes []*treenode, path string, buf []byte) (*treenode, *stringList, bool) {
 	for _, node := range nodes {
-		if node.isParam {
-			if i >= 0 {
-				value := path[:i]
-				if _, ok := t.validators.IsValid(value, node.paramConstraint); !ok {
-					continue
-				}
-				path = path[i:]
-				t, paramValues, s := t.search(node.children, path)
-				paramValues = addItem(paramValues, value)
-				return t, paramValues, s
+		if n, paramValues, ok := t.match(node, path, buf); ok {
+			return n, paramValues, true
+		}
+	}
+	return nil, nil, false
+}
+
+// match tests whether node (or one of its descendants) matches path.
+func (t *tree) match(node *treenode, path string, buf []byte) (*treenode, *stringList, bool) {
+	if node.isCatchAll {
+		// catch-all consumes the remainder of the path, including any slashes
+		if node.handlers == nil {
+			return nil, nil, false
+		}
+		if _, ok := t.validators.IsValid(path, node.paramConstraint); !ok {
+			return nil, nil, false
+		}
+		return node, newStringList(path), true
+	}
+
+	if node.isParam {
+		i := strings.IndexByte(path, byte('/'))
+		if i < 0 {
+			i = len(path)
+		}
+		// A parameter normally consumes the whole segment, but one followed
+		// by a literal within the same segment, e.g. {name}.{ext}, can end
+		// wherever that literal appears. Values ending at a literal are
+		// tried first, longest first, so that {name}.{ext} takes precedence
+		// over {name}; the whole segment is tried last.
+		for j := 1; j <= i; j++ {
+			k := i - j
+			if k == 0 {
+				k = i
+			}
+			if k < i && !node.hasChildStartingWith(path[k]) {
+				continue
 			}
-			if _, ok := t.validators.IsValid(path, node.paramConstraint); !ok {
+			value := path[:k]
+			if _, ok := t.validators.IsValid(value, node.paramConstraint); !ok {
 				continue
 			}
-			paramValues := newStringList(path)
-			return node, paramValues, true
+			rest := path[k:]
+			if len(rest) == 0 && node.handlers != nil {
+				return node, newStringList(value), true
+			}
+			if n, paramValues, ok := t.search(node.children, rest, buf); ok {
+				return n, addItem(paramValues, value), true
+			}
 		}
+		return nil, nil, false
+	}
 
-		n := node
-		for j := 0; j < len(node.label); j++ {
-			if len(path) <= j || path[j] != node.label[j] {
-				n = nil
-				break
+	l := len(node.label)
+	if len(path) < l {
+		return nil, nil, false
+	}
+	if buf == nil {
+		if path[:l] != node.label {
+			return nil, nil, false
+		}
+	} else if !strings.EqualFold(path[:l], node.label) {
+		return nil, nil, false
+	}
+	rest := path[l:]
+	var n *treenode
+	var paramValues *stringList
+	ok := len(rest) == 0 && node.handlers != nil
+	if ok {
+		n = node
+	} else {
+		// an empty remainder can still match a catch-all child
+		n, paramValues, ok = t.search(node.children, rest, buf)
+	}
+	if ok && buf != nil {
+		copy(buf[len(buf)-len(path):], node.label)
+	}
+	return n, paramValues, ok
+}
+
+// Routes returns details of every pattern-method combination held in
+// the tree, sorted by pattern and then method.
+func (t *tree) Routes() []RouteInfo {
+	var routes routeInfos
+	for _, n := range t.Root().children {
+		routes = n.routes(nil, t.GlobalCORS, routes)
+	}
+	sort.Sort(routes)
+	return routes
+}
+
+// routes appends the details of the node and its children to the routes
+// slice. Parts holds the ancestor nodes forming the pattern prefix.
+func (n *treenode) routes(parts []*treenode, globalCORS *CORSConfig, routes []RouteInfo) []RouteInfo {
+	parts = append(parts, n)
+	if n.handlers != nil {
+		// param names are held in reverse order
+		var names []string
+		if n.paramNames != nil {
+			for i := len(n.paramNames.items) - 1; i >= 0; i-- {
+				names = append(names, n.paramNames.items[i])
 			}
 		}
-		if n != nil {
-			path = path[len(n.label):]
-			if len(path) == 0 {
-				return n, nil, true
+		pattern := ""
+		constraints := make(map[string]string)
+		j := 0
+		for _, p := range parts {
+			if !p.isParam {
+				pattern += p.label
+				continue
+			}
+			name := ""
+			if j < len(names) {
+				name = names[j]
+			}
+			j++
+			constraints[name] = p.paramConstraint
+			pattern += p.paramPattern(name)
+		}
+		cors := n.CORSConfig
+		if cors == nil {
+			cors = globalCORS
+		}
+		for method, reg := range n.registered {
+			routes = append(routes, RouteInfo{
+				Pattern:     pattern,
+				Method:      method,
+				ParamNames:  names,
+				Constraints: constraints,
+				Handler:     reg.handlerName(),
+				Middleware:  middlewareNames(reg.middleware),
+				CORSConfig:  cors,
+			})
+		}
+		for method, vs := range n.versions {
+			for _, v := range vs {
+				routes = append(routes, RouteInfo{
+					Pattern:     pattern,
+					Method:      method,
+					ParamNames:  names,
+					Constraints: constraints,
+					Handler:     registration{handlerFunc: v.raw, name: v.name}.handlerName(),
+					Middleware:  middlewareNames(v.Middleware),
+					CORSConfig:  cors,
+					Version:     v.Version,
+					MediaType:   v.MediaType,
+				})
 			}
-			return t.search(n.children, path)
 		}
 	}
-	return nil, nil, false
+	for _, c := range n.children {
+		routes = c.routes(parts, globalCORS, routes)
+	}
+	return routes
+}
+
+// paramPattern returns the pattern syntax of a parameter node.
+func (n *treenode) paramPattern(name string) string {
+	if n.isCatchAll {
+		name = "*" + name
+	}
+	if n.paramConstraint != "" {
+		name += ":" + n.paramConstraint
+	}
+	return "{" + name + "}"
 }
 
 func (t *tree) GetStats() treeStats {
@@ -157,13 +517,18 @@ type treeStats struct {
 }
 
 type treenode struct {
-	children        []*treenode
-	label           string
-	handlers        map[string]ContextHandlerFunc
-	paramNames      *stringList
-	isParam         bool
-	paramConstraint string
-	CORSConfig      *CORSConfig
+	children         []*treenode
+	label            string
+	handlers         map[string]ContextHandlerFunc
+	versions         map[string][]VersionedHandler
+	registered       map[string]registration
+	defaults         map[string]map[string]string
+	paramNames       *stringList
+	paramConstraints map[string]string
+	isParam          bool
+	isCatchAll       bool
+	paramConstraint  string
+	CORSConfig       *CORSConfig
 }
 
 func (n *treenode) insert(child *treenode) {
@@ -183,23 +548,24 @@ func (n *treenode) addParamNode(pattern string) (*treenode, *stringList, bool) {
 	if i >= 0 {
 		node, paramNames := n.addNode(pattern[:i])
 		pattern = pattern[i+1:]
-		i := strings.IndexByte(pattern, byte('}'))
+		i := paramEnd(pattern)
 		if i < 0 {
 			panic("invalid route syntax: {" + pattern)
 		}
 
-		nc := strings.Split(pattern[:i], ":") // split {name:constraint}
-		name := strings.TrimSpace(nc[0])
-		constraint := ""
-		if len(nc) > 1 {
-			constraint = strings.TrimSpace(strings.Join(nc[1:], ""))
-		}
-		pn := node.addParamChild(constraint)
+		name, constraint, catchAll := parseParam(pattern[:i])
+		pn := node.addParamChild(constraint, catchAll)
 		paramNames = addItem(paramNames, name)
 		pattern = pattern[i+1:]
 		if len(pattern) == 0 {
 			return pn, paramNames, true
 		}
+		if catchAll {
+			panic("invalid route syntax: catch-all parameter must be last: {*" + name + "}" + pattern)
+		}
+		if pattern[0] == '{' {
+			panic("invalid route syntax: parameters must be separated: {" + name + "}" + pattern)
+		}
 
 		node, paramNames = pn.addNode(pattern)
 		paramNames = addItem(paramNames, name)
@@ -208,6 +574,158 @@ func (n *treenode) addParamNode(pattern string) (*treenode, *stringList, bool) {
 	return nil, nil, false
 }
 
+// paramEnd returns the index of the brace closing the pattern parameter
+// at the start of s, or -1 if there is none. Braces within parenthesised
+// constraint args are ignored, so regular expressions such as
+// {code:regex(^[A-Z]{3}$)} can contain quantifiers.
+func paramEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '}':
+			return i
+		case '(':
+			j := closingParen(s, i)
+			if j < 0 {
+				return -1
+			}
+			i = j
+		}
+	}
+	return -1
+}
+
+// parseParam splits the contents of a pattern parameter, {name:constraint},
+// into the parameter name and constraint. Catch-all parameters, {*name},
+// capture the remainder of the path.
+func parseParam(s string) (name, constraint string, catchAll bool) {
+	nc := strings.SplitN(s, ":", 2) // split {name:constraint}
+	name = strings.TrimSpace(nc[0])
+	if len(nc) > 1 {
+		constraint = strings.TrimSpace(nc[1])
+	}
+	catchAll = strings.HasPrefix(name, "*")
+	if catchAll {
+		name = strings.TrimSpace(name[1:])
+	}
+	return name, constraint, catchAll
+}
+
+// patternConstraints returns the constraints of the parameters in
+// pattern, keyed by parameter name. Unconstrained parameters are omitted.
+func patternConstraints(pattern string) map[string]string {
+	constraints := make(map[string]string)
+	for {
+		i := strings.IndexByte(pattern, byte('{'))
+		if i < 0 {
+			return constraints
+		}
+		pattern = pattern[i+1:]
+		j := paramEnd(pattern)
+		if j < 0 {
+			return constraints
+		}
+		name, constraint, _ := parseParam(pattern[:j])
+		if constraint != "" {
+			constraints[name] = constraint
+		}
+		pattern = pattern[j+1:]
+	}
+}
+
+// expansion is a pattern formed by omitting optional parameters, along
+// with the default values of those omitted.
+type expansion struct {
+	pattern  string
+	defaults []paramDefault
+}
+
+type paramDefault struct {
+	name       string
+	value      string
+	constraint string
+}
+
+// optionalParam splits the name of an optional parameter, {name?} or
+// {name=default}, into the name and default value.
+func optionalParam(s string) (name, def string, optional bool) {
+	if strings.HasSuffix(s, "?") {
+		return strings.TrimSpace(s[:len(s)-1]), "", true
+	}
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+	}
+	return s, "", false
+}
+
+// expandOptional returns pattern, with any optional parameter markers
+// removed, followed by the shorter patterns formed by omitting each of
+// the optional parameters at the end of pattern in turn.
+// Optional parameters must occupy whole segments at the end of the
+// pattern, otherwise expandOptional panics.
+func expandOptional(pattern string) []expansion {
+	full := ""
+	var cuts []int
+	var defaults []paramDefault
+	for {
+		i := strings.IndexByte(pattern, byte('{'))
+		if i < 0 || paramEnd(pattern[i+1:]) < 0 {
+			if len(cuts) > 0 && pattern != "" {
+				panic("invalid route syntax: optional parameters must be trailing segments: " + pattern)
+			}
+			full += pattern
+			break
+		}
+		if len(cuts) > 0 && pattern[:i] != "/" {
+			panic("invalid route syntax: optional parameters must be trailing segments: " + pattern)
+		}
+		full += pattern[:i]
+		pattern = pattern[i+1:]
+		j := paramEnd(pattern)
+		param := pattern[:j]
+		pattern = pattern[j+1:]
+
+		nc := strings.SplitN(param, ":", 2)
+		name, def, optional := optionalParam(nc[0])
+		if !optional {
+			if len(cuts) > 0 {
+				panic("invalid route syntax: optional parameters must be trailing segments: {" + param + "}")
+			}
+			full += "{" + param + "}"
+			continue
+		}
+		if !strings.HasSuffix(full, "/") || (pattern != "" && pattern[0] != '/') {
+			panic("invalid route syntax: optional parameters must be whole segments: {" + param + "}")
+		}
+		cuts = append(cuts, len(full)-1)
+		constraint := ""
+		if len(nc) > 1 {
+			constraint = strings.TrimSpace(nc[1])
+		}
+		if def != "" {
+			defaults = append(defaults, paramDefault{strings.TrimPrefix(name, "*"), def, constraint})
+		} else {
+			defaults = append(defaults, paramDefault{})
+		}
+		nc[0] = name
+		full += "{" + strings.Join(nc, ":") + "}"
+	}
+
+	expansions := []expansion{{pattern: full}}
+	for k := len(cuts) - 1; k >= 0; k-- {
+		e := expansion{pattern: full[:cuts[k]]}
+		if e.pattern == "" {
+			e.pattern = "/"
+		}
+		for _, d := range defaults[k:] {
+			if d.name != "" {
+				e.defaults = append(e.defaults, d)
+			}
+		}
+		expansions = append(expansions, e)
+	}
+	return expansions
+}
+
 func (n *treenode) addNode(pattern string) (*treenode, *stringList) {
 	// handle any parameters first...
 	node, params, ok := n.addParamNode(pattern)
@@ -245,7 +763,12 @@ func (n *treenode) addNode(pattern string) (*treenode, *stringList) {
 			// child's children to a new slice containing only the new grandchiild node
 			gc.children, child.children = child.children, []*treenode{gc}
 			gc.handlers, child.handlers = child.handlers, nil
-			//n.ParamNames, node.ParamNames = node.ParamNames, nil
+			gc.versions, child.versions = child.versions, nil
+			gc.registered, child.registered = child.registered, nil
+			gc.defaults, child.defaults = child.defaults, nil
+			gc.paramNames, child.paramNames = child.paramNames, nil
+			gc.paramConstraints, child.paramConstraints = child.paramConstraints, nil
+			gc.CORSConfig, child.CORSConfig = child.CORSConfig, nil
 			// reset current node Label to "common" part...
 			child.label = pattern[:j]
 			pattern = pattern[j:]
@@ -264,26 +787,52 @@ func (n *treenode) addNode(pattern string) (*treenode, *stringList) {
 	return node, nil
 }
 
-func (n *treenode) addParamChild(constraint string) *treenode {
-	pc := n.paramChild(constraint)
+func (n *treenode) addParamChild(constraint string, catchAll bool) *treenode {
+	pc := n.paramChild(constraint, catchAll)
 	if pc == nil {
-		pc = &treenode{paramConstraint: constraint, isParam: true}
-		// append as last child to ensure after any non-parametised siblings
-		n.append(pc)
-		// ensure that any empty constraint is always the last sibling by
-		// checking penultimate sibling and moving to end if an empty constraint
-		c := n.children
-		l := len(c)
-		if l > 1 && c[l-2].isParam && c[l-2].paramConstraint == "" {
-			c[l-2], c[l-1] = c[l-1], c[l-2]
+		pc = &treenode{paramConstraint: constraint, isParam: true, isCatchAll: catchAll}
+		// insert after any non-parametised siblings and any parametised
+		// siblings of equal or higher priority; constrained parameters
+		// come before an empty constraint, and catch-alls are always last
+		i := len(n.children)
+		for i > 0 && n.children[i-1].priority() > pc.priority() {
+			i--
 		}
+		n.append(pc)
+		copy(n.children[i+1:], n.children[i:])
+		n.children[i] = pc
 	}
 	return pc
 }
 
-func (n *treenode) paramChild(constraint string) *treenode {
+// hasChildStartingWith returns true if the node has a non-parametised
+// child with a label starting with b.
+func (n *treenode) hasChildStartingWith(b byte) bool {
+	for _, c := range n.children {
+		if !c.isParam && c.label[0] == b {
+			return true
+		}
+	}
+	return false
+}
+
+// priority returns the matching order of a node amongst its siblings,
+// lower values being matched first.
+func (n *treenode) priority() int {
+	switch {
+	case n.isCatchAll:
+		return 3
+	case n.isParam && n.paramConstraint == "":
+		return 2
+	case n.isParam:
+		return 1
+	}
+	return 0
+}
+
+func (n *treenode) paramChild(constraint string, catchAll bool) *treenode {
 	for _, c := range n.children {
-		if c.isParam && c.paramConstraint == constraint {
+		if c.isParam && c.isCatchAll == catchAll && c.paramConstraint == constraint {
 			return c
 		}
 	}
@@ -304,8 +853,8 @@ func (n *treenode) structure(l int) string {
 	handlers := ""
 	pns := ""
 	if n.handlers != nil {
-		for k, h := range n.handlers {
-			name := extractFnName(h)
+		for k, reg := range n.registered {
+			name := strings.Join(reg.names(), " > ")
 			handlers += fmt.Sprintf("[%s: %s]", k, name)
 		}
 		handlers = "Handlers " + handlers
@@ -319,7 +868,9 @@ func (n *treenode) structure(l int) string {
 		pns += "]"
 	}
 
-	if n.isParam {
+	if n.isCatchAll {
+		s += fmt.Sprintf("%s>CatchAll: %q\t%s\t%s\n", tab, n.paramConstraint, handlers, pns)
+	} else if n.isParam {
 		s += fmt.Sprintf("%s>Param: %q\t%s\t%s\n", tab, n.paramConstraint, handlers, pns)
 	} else {
 		s += fmt.Sprintf("%s>Label: %q\t%s\t%s\n", tab, n.label, handlers, pns)
@@ -369,8 +920,28 @@ func comlen(a, b string) int {
 	return al
 }
 
+func middlewareNames(mw []Middleware) []string {
+	var names []string
+	for _, m := range mw {
+		names = append(names, extractFnName(m))
+	}
+	return names
+}
+
 func extractFnName(f interface{}) string {
 	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	// method values have a -fm suffix and closures are named after their
+	// enclosing function, e.g. pkg.RequireRole.func1, so remove both, along
+	// with the type arguments of generic functions, e.g. pkg.Typed[...]
+	name = strings.TrimSuffix(name, "-fm")
+	name = strings.Replace(name, "[...]", "", -1)
+	for {
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 || !isClosureName(name[i+1:]) {
+			break
+		}
+		name = name[:i]
+	}
 	for i := len(name) - 1; i >= 0; i-- {
 		if name[i] == '.' {
 			return name[i+1:]
@@ -378,3 +949,18 @@ func extractFnName(f interface{}) string {
 	}
 	return name
 }
+
+// isClosureName returns true if s is the generated name of a closure,
+// e.g. func1, or of a closure nested within another, e.g. 1.
+func isClosureName(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
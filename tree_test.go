@@ -416,6 +416,543 @@ func TestMatchingPathElementShorterThanLabelReturnsFalseIfNoOtherMatches(t *test
 	}
 }
 
+func TestSplittingNodeMovesParamNamesToNewChild(t *testing.T) {
+	want := "45"
+	validator := mockValidationHandler{valid: true}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/Sleepers/{sleeperID}/books", "GET", testFunc)
+	testTree.AddHandlerFunc("/Sleepers/{sleeperID}/bikes", "GET", testFunc2)
+	resource, _ := testTree.GetResource("/Sleepers/45/books")
+	got := resource.RouteParams["sleeperID"]
+	if got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+}
+
+func TestSplittingNodeMovesCORSConfigToNewChild(t *testing.T) {
+	want := "http://somewhere.com"
+	testTree := tree{}
+	testTree.AddHandlerFunc("/Sleepers", "GET", testFunc)
+	testTree.SetCORS("/Sleepers", CORSConfig{Origins: []string{want}})
+	testTree.AddHandlerFunc("/Slippers", "GET", testFunc2)
+	resource, _ := testTree.GetResource("/Sleepers")
+	if resource.CORSConfig == nil {
+		t.Errorf("CORSConfig = nil, want Origins %q", want)
+		return
+	}
+	got := resource.CORSConfig.Origins[0]
+	if got != want {
+		t.Errorf("Origin = %q, want %q", got, want)
+	}
+}
+
+func TestCatchAllParameterCapturesRemainingPath(t *testing.T) {
+	want := "docs/2017/report.pdf"
+	validator := mockValidationHandler{valid: true}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc)
+	resource, ok := testTree.GetResource("/files/docs/2017/report.pdf")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := resource.RouteParams["path"]
+	if got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+}
+
+func TestCatchAllParameterCapturesEmptyRemainingPath(t *testing.T) {
+	want := "testFunc"
+	validator := mockValidationHandler{valid: true}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc)
+	resource, ok := testTree.GetResource("/files/")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"])
+	if got != want {
+		t.Errorf("Handler = %q, want %q", got, want)
+	}
+	if p := resource.RouteParams["path"]; p != "" {
+		t.Errorf("Value = %q, want %q", p, "")
+	}
+}
+
+func TestCatchAllParameterFollowingOtherParameters(t *testing.T) {
+	want := "bucket1|a/b/c.txt"
+	validator := mockValidationHandler{valid: true}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/s3/{bucket}/{*key}", "GET", testFunc)
+	resource, _ := testTree.GetResource("/s3/bucket1/a/b/c.txt")
+	got := resource.RouteParams["bucket"] + "|" + resource.RouteParams["key"]
+	if got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+}
+
+func TestCatchAllParameterIsLastToMatch(t *testing.T) {
+	want := "testFunc2"
+	validator := mockValidationHandler{valid: true}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc)
+	testTree.AddHandlerFunc("/files/{name}", "GET", testFunc2)
+	resource, _ := testTree.GetResource("/files/report.pdf")
+	got := extractFnName(resource.Handlers["GET"])
+	if got != want {
+		t.Errorf("Handler = %q, want %q", got, want)
+	}
+}
+
+func TestCatchAllParameterIsLastSibling(t *testing.T) {
+	want := `>Label: "/files/"		` + `
+	>Label: "static"	Handlers [GET: testFunc3]	 ParamNames []` + `
+	>Param: "alpha"	Handlers [GET: testFunc3]	 ParamNames [name,]` + `
+	>Param: ""	Handlers [GET: testFunc2]	 ParamNames [name,]` + `
+	>CatchAll: ""	Handlers [GET: testFunc]	 ParamNames [path,]
+`
+	testTree := tree{}
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc)
+	testTree.AddHandlerFunc("/files/{name}", "GET", testFunc2)
+	testTree.AddHandlerFunc("/files/{name:alpha}", "GET", testFunc3)
+	testTree.AddHandlerFunc("/files/static", "GET", testFunc3)
+	got := testTree.Structure()
+	if got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+}
+
+func TestCatchAllParameterIsValidatedAgainstConstraint(t *testing.T) {
+	want := false
+	validator := mockValidationHandler{valid: false}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/files/{*path:prefix(docs)}", "GET", testFunc)
+	_, got := testTree.GetResource("/files/images/cat.png")
+	if got != want {
+		t.Errorf("Result = %t, want %t", got, want)
+	}
+}
+
+func TestAddHandlerFuncPanicsWhenCatchAllParameterNotLast(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+
+	testTree := tree{}
+	testTree.AddHandlerFunc("/files/{*path}/info", "GET", testFunc)
+}
+
+func TestSearchBacktracksToSiblingParamWhenDeeperMatchFails(t *testing.T) {
+	want := "testFunc2|bob"
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/users/{id:int32}/posts", "GET", testFunc)
+	testTree.AddHandlerFunc("/users/{name:alpha}/profile", "GET", testFunc2)
+	resource, ok := testTree.GetResource("/users/bob/profile")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["name"]
+	if got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestSearchBacktracksFromConstrainedToUnconstrainedParam(t *testing.T) {
+	want := "testFunc2|42"
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/users/{id:int32}/posts", "GET", testFunc)
+	testTree.AddHandlerFunc("/users/{name}/profile", "GET", testFunc2)
+	resource, ok := testTree.GetResource("/users/42/profile")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["name"]
+	if got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestSearchBacktracksFromStaticToParam(t *testing.T) {
+	want := "testFunc2|special"
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/files/special/info", "GET", testFunc)
+	testTree.AddHandlerFunc("/files/{name}/data", "GET", testFunc2)
+	resource, ok := testTree.GetResource("/files/special/data")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["name"]
+	if got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestSearchBacktracksFromParamToCatchAll(t *testing.T) {
+	want := "testFunc2|a/b/c"
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/files/{name}/data", "GET", testFunc)
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc2)
+	resource, ok := testTree.GetResource("/files/a/b/c")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["path"]
+	if got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestSearchBacktracksFromStaticToCatchAll(t *testing.T) {
+	want := "testFunc2|special/other"
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/files/special/info", "GET", testFunc)
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc2)
+	resource, ok := testTree.GetResource("/files/special/other")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["path"]
+	if got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestSearchPriorityWhenSeveralRoutesMatch(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/files/static", "static"},
+		{"/files/123", "constrained"},
+		{"/files/abc", "unconstrained"},
+		{"/files/abc/def", "catchall"},
+	}
+	handler := func(s string) ContextHandlerFunc {
+		return func(c *Context) {
+			c.X = s
+		}
+	}
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/files/{*path}", "GET", handler("catchall"))
+	testTree.AddHandlerFunc("/files/{name}", "GET", handler("unconstrained"))
+	testTree.AddHandlerFunc("/files/{id:int32}", "GET", handler("constrained"))
+	testTree.AddHandlerFunc("/files/static", "GET", handler("static"))
+
+	for _, test := range tests {
+		resource, ok := testTree.GetResource(test.path)
+		if !ok {
+			t.Errorf("Resource not found for %q", test.path)
+			continue
+		}
+		c := &Context{}
+		resource.Handlers["GET"](c)
+		if got := c.X; got != test.want {
+			t.Errorf("Route for %q = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestSearchReturnsFalseWhenPathMatchesNodeWithoutHandlers(t *testing.T) {
+	want := false
+	testTree := tree{}
+
+	testTree.AddHandlerFunc("/Sleepers", "GET", testFunc)
+	testTree.AddHandlerFunc("/Sugar", "GET", testFunc)
+	_, got := testTree.GetResource("/S")
+	if got != want {
+		t.Errorf("Result = %t, want %t", got, want)
+	}
+}
+
+func TestSearchReturnsFalseWhenParameterSegmentEmpty(t *testing.T) {
+	want := false
+	validator := mockValidationHandler{valid: true}
+	testTree := tree{validators: validator}
+
+	testTree.AddHandlerFunc("/users/{id}/posts", "GET", testFunc)
+	_, got := testTree.GetResource("/users//posts")
+	if got != want {
+		t.Errorf("Result = %t, want %t", got, want)
+	}
+}
+
+func TestRetrievingMultipleParametersWithinSingleSegment(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    string
+	}{
+		{"/img/{name}.{ext:alpha}", "/img/cat.png", "cat|png"},
+		{"/img/{name}.{ext:alpha}", "/img/my.cat.png", "my.cat|png"},
+		{"/range/{from:int32}-{to:int32}", "/range/5-10", "5|10"},
+		{"/range/{from:int32}-{to:int32}", "/range/-5--3", "-5|-3"},
+		{"/v{major:int32}.{minor:int32}/docs", "/v1.12/docs", "1|12"},
+	}
+	for _, test := range tests {
+		testTree := tree{validators: newValidationHandler()}
+		testTree.AddHandlerFunc(test.pattern, "GET", testFunc)
+		resource, ok := testTree.GetResource(test.path)
+		if !ok {
+			t.Errorf("Resource not found for %q", test.path)
+			continue
+		}
+		routes := testTree.Routes()
+		names := routes[0].ParamNames
+		got := resource.RouteParams[names[0]] + "|" + resource.RouteParams[names[1]]
+		if got != test.want {
+			t.Errorf("Values for %q = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestSegmentWithMultipleParametersFailsWhenLiteralMissing(t *testing.T) {
+	want := false
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/img/{name}.{ext:alpha}", "GET", testFunc)
+	_, got := testTree.GetResource("/img/cat")
+	if got != want {
+		t.Errorf("Result = %t, want %t", got, want)
+	}
+}
+
+func TestSegmentWithMultipleParametersAndWholeSegmentParameter(t *testing.T) {
+	want := "testFunc2|cat.png"
+	testTree := tree{validators: newValidationHandler()}
+
+	testTree.AddHandlerFunc("/img/{name}.{ext:int32}", "GET", testFunc)
+	testTree.AddHandlerFunc("/img/{file}", "GET", testFunc2)
+	resource, ok := testTree.GetResource("/img/cat.png")
+	if !ok {
+		t.Errorf("Resource not found")
+		return
+	}
+	got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["file"]
+	if got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestSegmentWithMultipleParametersTakesPrecedenceOverSameParameter(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/img/cat.png", "testFunc2|cat|png"},
+		{"/img/my.cat.png", "testFunc2|my.cat|png"},
+		{"/img/cat.7", "testFunc|cat.7|"},
+		{"/img/cat", "testFunc|cat|"},
+	}
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/img/{name}", "GET", testFunc)
+	testTree.AddHandlerFunc("/img/{name}.{ext:alpha}", "GET", testFunc2)
+	for _, test := range tests {
+		resource, ok := testTree.GetResource(test.path)
+		if !ok {
+			t.Errorf("Resource not found for %q", test.path)
+			continue
+		}
+		got := extractFnName(resource.Handlers["GET"]) + "|" + resource.RouteParams["name"] + "|" + resource.RouteParams["ext"]
+		if got != test.want {
+			t.Errorf("Result for %q = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestRetrievingParameterWithRegexConstraint(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/codes/ABC12", true},
+		{"/codes/ABC123", false},
+		{"/codes/abc12", false},
+		{"/codes/AB12", false},
+	}
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/codes/{code:regex(^[A-Z]{3}[0-9]{2}$)}", "GET", testFunc)
+	for _, test := range tests {
+		resource, got := testTree.GetResource(test.path)
+		if got != test.want {
+			t.Errorf("Result for %q = %t, want %t", test.path, got, test.want)
+			continue
+		}
+		if got && resource.RouteParams["code"] != test.path[7:] {
+			t.Errorf("code = %q, want %q", resource.RouteParams["code"], test.path[7:])
+		}
+	}
+}
+
+func TestTreeRoutesReturnsRegexConstraint(t *testing.T) {
+	want := "/codes/{code:regex(^[A-Z]{3}:[0-9]{2}$)}/items"
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc(want, "GET", testFunc)
+	got := testTree.Routes()[0].Pattern
+	if got != want {
+		t.Errorf("Pattern = %q, want %q", got, want)
+	}
+}
+
+func TestAddHandlerFuncPanicsWhenRegexConstraintInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/codes/{code:regex(^[A-Z]**$)}", "GET", testFunc)
+}
+
+func TestCaseInsensitivePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users/Jeff/POSTS", "/users/Jeff/posts"},
+		{"/USERS/jeff/Posts/Mine", "/users/jeff/posts/Mine"},
+		{"/Users/Jeff/Profile", "/Users/Jeff/Profile"},
+		{"/Files/A/B.txt", "/files/A/B.txt"},
+		{"/Fruit", "not found"},
+	}
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/users/{name}/posts", "GET", testFunc)
+	testTree.AddHandlerFunc("/users/{name}/posts/{id}", "GET", testFunc)
+	testTree.AddHandlerFunc("/Users/{name}/Profile", "GET", testFunc)
+	testTree.AddHandlerFunc("/files/{*path}", "GET", testFunc)
+	for _, test := range tests {
+		got, ok := testTree.CaseInsensitivePath(test.path)
+		if !ok {
+			got = "not found"
+		}
+		if got != test.want {
+			t.Errorf("Path for %q = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestRetrievingOptionalParameters(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/reports/2017/6/2", "2017|6|2"},
+		{"/reports/2017/6", "2017|6|1"},
+		{"/reports/2017", "2017||1"},
+		{"/reports/2017/", "not found"},
+		{"/reports", "not found"},
+		{"/reports/2017/june", "not found"},
+	}
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/reports/{year:int32}/{month?:int32}/{page=1:int32}", "GET", testFunc)
+	for _, test := range tests {
+		resource, ok := testTree.GetResource(test.path)
+		got := "not found"
+		if ok {
+			resource.setDefaults("GET")
+			p := resource.RouteParams
+			got = p["year"] + "|" + p["month"] + "|" + p["page"]
+		}
+		if got != test.want {
+			t.Errorf("Params for %q = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestOptionalParameterDefaultsOnlyApplyToTheirMethod(t *testing.T) {
+	want := "1|"
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/reports/{year}/{month=1}", "GET", testFunc)
+	testTree.AddHandlerFunc("/reports/{year}", "POST", testFunc)
+
+	get, _ := testTree.GetResource("/reports/2020")
+	get.setDefaults("GET")
+	post, _ := testTree.GetResource("/reports/2020")
+	post.setDefaults("POST")
+
+	got := get.RouteParams["month"] + "|" + post.RouteParams["month"]
+	if got != want {
+		t.Errorf("Month params = %q, want %q", got, want)
+	}
+}
+
+func TestTreeRoutesListsOptionalParameterPatterns(t *testing.T) {
+	want := "/reports/{year:int32},/reports/{year:int32}/{month:int32}"
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/reports/{year:int32}/{month?:int32}", "GET", testFunc)
+	got := ""
+	for _, r := range testTree.Routes() {
+		got += "," + r.Pattern
+	}
+	got = got[1:]
+	if got != want {
+		t.Errorf("Patterns = %q, want %q", got, want)
+	}
+}
+
+func TestAddHandlerFuncOptionalParameterSyntaxPanics(t *testing.T) {
+	tests := []string{
+		"/reports/{month?}/{year}",
+		"/reports/{month?}/summary",
+		"/reports/r{month?}",
+		"/reports/{month?}.csv",
+		"/reports/{page=one:int32}",
+	}
+	for _, pattern := range tests {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("The code did not panic for %q", pattern)
+				}
+			}()
+			testTree := tree{validators: newValidationHandler()}
+			testTree.AddHandlerFunc(pattern, "GET", testFunc)
+		}()
+	}
+}
+
+func TestAddHandlerFuncPanicsWhenOptionalParameterDuplicatesRoute(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	testTree := tree{validators: newValidationHandler()}
+	testTree.AddHandlerFunc("/reports", "GET", testFunc)
+	testTree.AddHandlerFunc("/reports/{month?}", "GET", testFunc)
+}
+
+func TestAddHandlerFuncPanicsWhenParametersAdjacent(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+
+	testTree := tree{}
+	testTree.AddHandlerFunc("/img/{name}{ext}", "GET", testFunc)
+}
+
 func TestAddHandlerFuncPanicsWhenMismatchingParameterBraces(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
@@ -878,6 +1415,66 @@ func TestTreeStructureWithTwoStaticRoutesWithParametizedSegments(t *testing.T) {
 	}
 }
 
+func TestTreeRoutesReturnsPatternsAndMethods(t *testing.T) {
+	want := "GET /Cheese/{desire}/sleeper/{eyeball:int32},GET /Onions/{season}/spring,POST /Onions/{season}/spring,"
+	testTree := tree{}
+	testTree.AddHandlerFunc("/Onions/{season}/spring", "POST", testFunc3)
+	testTree.AddHandlerFunc("/Cheese/{desire}/sleeper/{eyeball:int32}", "GET", testFunc)
+	testTree.AddHandlerFunc("/Onions/{season}/spring", "GET", testFunc2)
+	got := ""
+	for _, r := range testTree.Routes() {
+		got += r.Method + " " + r.Pattern + ","
+	}
+	if got != want {
+		t.Errorf("Routes = %q, want %q", got, want)
+	}
+}
+
+func TestTreeRoutesReturnsParamNamesInPatternOrder(t *testing.T) {
+	want := "desire,eyeball,path"
+	testTree := tree{}
+	testTree.AddHandlerFunc("/Cheese/{desire}/sleeper/{eyeball:int32}/{*path}", "GET", testFunc)
+	got := strings.Join(testTree.Routes()[0].ParamNames, ",")
+	if got != want {
+		t.Errorf("ParamNames = %q, want %q", got, want)
+	}
+}
+
+func TestTreeRoutesReturnsParamConstraints(t *testing.T) {
+	want := "|int32"
+	testTree := tree{}
+	testTree.AddHandlerFunc("/Cheese/{desire}/sleeper/{eyeball:int32}", "GET", testFunc)
+	c := testTree.Routes()[0].Constraints
+	got := c["desire"] + "|" + c["eyeball"]
+	if got != want {
+		t.Errorf("Constraints = %q, want %q", got, want)
+	}
+}
+
+func TestTreeRoutesReturnsHandlerName(t *testing.T) {
+	want := "testFunc2"
+	testTree := tree{}
+	testTree.AddHandlerFunc("/Cheese", "GET", testFunc2)
+	got := testTree.Routes()[0].Handler
+	if got != want {
+		t.Errorf("Handler = %q, want %q", got, want)
+	}
+}
+
+func TestTreeRoutesReturnsEffectiveCORSConfig(t *testing.T) {
+	want := "http://here.com|http://global.com"
+	testTree := tree{}
+	testTree.SetGlobalCORS(CORSConfig{Origins: []string{"http://global.com"}})
+	testTree.AddHandlerFunc("/Cheese", "GET", testFunc)
+	testTree.AddHandlerFunc("/Onions", "GET", testFunc)
+	testTree.SetCORS("/Cheese", CORSConfig{Origins: []string{"http://here.com"}})
+	routes := testTree.Routes()
+	got := routes[0].CORSConfig.Origins[0] + "|" + routes[1].CORSConfig.Origins[0]
+	if got != want {
+		t.Errorf("CORS Origins = %q, want %q", got, want)
+	}
+}
+
 // mocks
 
 type mockValidationHandler struct {
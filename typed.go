@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package mango
+
+import "reflect"
+
+// TypedFunc is the signature for handler functions which receive a bound
+// and validated request model, and return a response model or an error.
+type TypedFunc[Req, Res any] func(c *Context, req Req) (Res, error)
+
+// Typed returns a ContextHandlerFunc which binds the request to a new Req,
+// validates it, calls fn and then sends the returned Res, encoded
+// according to the request Accept header:
+//
+//	func createUser(c *mango.Context, req CreateUser) (User, error) {
+//		...
+//		c.Respond().WithStatus(201)
+//		return user, nil
+//	}
+//
+//	r.Post("/users", mango.Typed(createUser))
+//
+// Req must be a struct type, and is bound and validated in the same way
+// as BindAndValidate, which sends the response if either fails. The
+// response status is 200 unless fn sets it. Errors returned by fn are
+// mapped to the response in the same way as those returned by an
+// ErrorHandlerFunc, replacing any response fn had prepared, and the Res
+// value is discarded.
+// Use TypedRoute to register the handler and record the Req and Res
+// types as the route models at the same time.
+func Typed[Req, Res any](fn TypedFunc[Req, Res]) ContextHandlerFunc {
+	return func(c *Context) {
+		var req Req
+		if !c.BindAndValidate(&req) {
+			return
+		}
+		res, err := fn(c, req)
+		if err != nil {
+			c.status, c.model, c.payload, c.responseReady = 0, nil, nil, false
+			c.mapError(err)
+			return
+		}
+		c.Respond().WithModel(res)
+	}
+}
+
+// TypedRoute registers a Typed handler for fn using register, which is
+// one of the route registration methods of a Router or Group, and records
+// the Req and Res types as the route models, in the same way as the Route
+// Models method:
+//
+//	mango.TypedRoute(r.Post, "/users", createUser)
+//
+// The Route returned by register is returned.
+func TypedRoute[Req, Res any](register func(string, ContextHandlerFunc, ...Middleware) *Route, pattern string, fn TypedFunc[Req, Res], mw ...Middleware) *Route {
+	rt := register(pattern, Typed(fn), mw...)
+	rt.setModels(modelType[Req](), modelType[Res]())
+	return rt
+}
+
+// modelType returns the type of T, or nil if T is an interface type, as
+// no model can be described for it.
+func modelType[T any]() reflect.Type {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	return t
+}
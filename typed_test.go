@@ -0,0 +1,150 @@
+//go:build go1.18
+// +build go1.18
+
+package mango
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type typedRequest struct {
+	ID   int64  `json:"-" route:"id"`
+	Name string `json:"name" validate:"alpha"`
+}
+
+type typedResponse struct {
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+}
+
+func typedHandler(c *Context, req typedRequest) (typedResponse, error) {
+	if req.Name == "Error" {
+		c.Respond().WithStatus(201)
+		return typedResponse{}, NewProblem(409, "already exists")
+	}
+	c.Respond().WithStatus(201)
+	return typedResponse{ID: req.ID, Message: "Hello " + req.Name}, nil
+}
+
+func TestTypedHandler(t *testing.T) {
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"name":"Mango"}`, 201, `{"id":34,"message":"Hello Mango"}`},
+		{`{"name":"Mango59"}`, 422,
+			`{"message":"The request model failed validation.","failures":{"name":[{"code":"alpha","message":"must contain only alpha characters."}]}}`},
+		{`{"name":`, 400, `{"message":"The request could not be bound: unexpected EOF"}`},
+		{`{"name":"Error"}`, 409, `{"detail":"already exists","status":409,"title":"Conflict"}`},
+	}
+	r := NewRouter()
+	r.Post("/fruit/{id:int64}", Typed(typedHandler))
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", "/fruit/34", bytes.NewBufferString(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s: Status = %d, want %d", test.body, w.Code, test.status)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != test.want {
+			t.Errorf("%s: Body = %q, want %q", test.body, got, test.want)
+		}
+	}
+}
+
+func TestTypedHandlerWithoutRequestBody(t *testing.T) {
+	tests := []struct {
+		url    string
+		status int
+		want   string
+	}{
+		{"/tags?prefix=x", 200, `["xa","xb"]`},
+		{"/tags", 500, "Internal Server Error"},
+	}
+	r := NewRouter()
+	r.Get("/tags", Typed(func(c *Context, req struct {
+		Prefix string `query:"prefix"`
+	}) ([]string, error) {
+		if req.Prefix == "" {
+			return nil, errors.New("missing prefix")
+		}
+		return []string{req.Prefix + "a", req.Prefix + "b"}, nil
+	}))
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s: Status = %d, want %d", test.url, w.Code, test.status)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != test.want {
+			t.Errorf("%s: Body = %q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+func TestRouteModelsAreReportedByRoutes(t *testing.T) {
+	r := NewRouter()
+	TypedRoute(r.Post, "/fruit/{id:int64}/{name?}", typedHandler)
+	r.Get("/fruit", testFunc)
+	routes := r.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("Route count = %d, want 3", len(routes))
+	}
+	for _, ri := range routes {
+		var wantReq, wantRes reflect.Type
+		if ri.Method == "POST" {
+			wantReq, wantRes = reflect.TypeOf(typedRequest{}), reflect.TypeOf(typedResponse{})
+		}
+		if ri.Request != wantReq || ri.Response != wantRes {
+			t.Errorf("%s %s: Models = %v, %v, want %v, %v", ri.Method, ri.Pattern, ri.Request, ri.Response, wantReq, wantRes)
+		}
+		if ri.Method == "POST" && ri.Handler != "Typed" {
+			t.Errorf("Handler = %q, want %q", ri.Handler, "Typed")
+		}
+	}
+}
+
+func TestTypedRouteModelsAreRecordedPerRegistration(t *testing.T) {
+	type otherRequest struct {
+		Colour string `json:"colour"`
+	}
+	r := NewRouter()
+	api := r.Group("/api")
+	TypedRoute(api.Version("1", "").Post, "/fruit/{id:int64}", typedHandler)
+	TypedRoute(api.Version("2", "").Post, "/fruit/{id:int64}", func(c *Context, req otherRequest) (typedResponse, error) {
+		return typedResponse{}, nil
+	})
+	r.Host("shop.example.com").Post("/api/fruit/{id:int64}", testFunc).Models(otherRequest{}, nil)
+	TypedRoute(api.Get, "/tags", func(c *Context, req struct{}) (interface{}, error) {
+		return nil, nil
+	})
+	tests := map[string][2]reflect.Type{
+		"POST /api/fruit/{id:int64} 1 ":                {reflect.TypeOf(typedRequest{}), reflect.TypeOf(typedResponse{})},
+		"POST /api/fruit/{id:int64} 2 ":                {reflect.TypeOf(otherRequest{}), reflect.TypeOf(typedResponse{})},
+		"POST /api/fruit/{id:int64}  shop.example.com": {reflect.TypeOf(otherRequest{}), nil},
+		"GET /api/tags  ":                              {reflect.TypeOf(struct{}{}), nil},
+	}
+	routes := r.Routes()
+	if len(routes) != len(tests) {
+		t.Fatalf("Route count = %d, want %d", len(routes), len(tests))
+	}
+	for _, ri := range routes {
+		k := ri.Method + " " + ri.Pattern + " " + ri.Version + " " + ri.Host
+		want, ok := tests[k]
+		if !ok {
+			t.Errorf("Unexpected route %q", k)
+		}
+		if ri.Request != want[0] || ri.Response != want[1] {
+			t.Errorf("%s: Models = %v, %v, want %v, %v", k, ri.Request, ri.Response, want[0], want[1])
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // ValidationFailure holds details about a validation failure. Code will
@@ -77,7 +78,11 @@ func (r *elementValidationHandler) IsValid(val interface{}, constraints string)
 			if len(args) > 0 {
 				name += "(" + strings.Join(args, ",") + ")"
 			}
-			fails = append(fails, ValidationFailure{name, v.FailureMsg()})
+			msg := v.FailureMsg()
+			if f, ok := v.(argFailureMsg); ok {
+				msg = f.failureMsg(args)
+			}
+			fails = append(fails, ValidationFailure{name, msg})
 		}
 	}
 	ok = len(fails) == 0
@@ -94,6 +99,25 @@ func (r *elementValidationHandler) ParseConstraints(constraints string) map[stri
 	for i := 0; i < len(constraints); i++ {
 		if constraints[i] == '(' {
 			brace++
+			if brace == 1 && name == "" && strings.TrimSpace(string(buf[:b])) == "regex" {
+				// Regular expressions are taken verbatim, as they can
+				// contain commas and parentheses of their own.
+				j := closingParen(constraints, i)
+				if j < 0 {
+					panic(fmt.Sprintf("illegal constraint format: %s", constraints))
+				}
+				results["regex"] = []string{constraints[i+1 : j]}
+				brace = 0
+				b = 0
+				i = j
+				for i+1 < len(constraints) && constraints[i+1] == ' ' {
+					i++
+				}
+				if i+1 < len(constraints) && constraints[i+1] == ',' {
+					i++
+				}
+				continue
+			}
 		}
 		if constraints[i] == ')' {
 			brace--
@@ -143,6 +167,60 @@ func (r *elementValidationHandler) ParseConstraints(constraints string) map[stri
 	return results
 }
 
+// closingParen returns the index of the parenthesis which closes the one
+// at index i of s, or -1 if there is none. Escaped characters and
+// character classes, as found in regular expressions, are skipped.
+func closingParen(s string, i int) int {
+	depth := 0
+	class := false
+	for i++; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case class:
+			class = s[i] != ']'
+		case s[i] == '[':
+			class = true
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// compile gives any validators in constraints which prepare their args
+// ahead of use, such as RegexValidator, the opportunity to do so. This
+// allows invalid args to be reported when a route is registered rather
+// than when a request is made.
+func (r *elementValidationHandler) compile(constraints string) error {
+	for name, args := range r.ParseConstraints(constraints) {
+		if c, ok := r.validators[name].(argCompiler); ok {
+			if err := c.compile(args); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// argFailureMsg is implemented by Validators whose failure message
+// depends on their args. Such validators are shared by concurrent
+// requests, so cannot hold the args of the last call for FailureMsg.
+type argFailureMsg interface {
+	failureMsg(args []string) string
+}
+
+// argCompiler is implemented by Validators which prepare their args
+// ahead of use, e.g. compiling a regular expression.
+type argCompiler interface {
+	compile(args []string) error
+}
+
 func newValidationHandler() ValidationHandler {
 	v := elementValidationHandler{}
 	v.validators = make(map[string]Validator)
@@ -1092,6 +1170,80 @@ func (v *EmailValidator) FailureMsg() string {
 	return "must be a valid email address."
 }
 
+// RegexValidator tests for a match with a regular expression.
+type RegexValidator struct {
+	mu    sync.RWMutex
+	cache map[string]*regexp.Regexp
+}
+
+// Validate tests for a match with a regular expression.
+// Returns true if val is a string matching the regular expression specified
+// in params. The expression is not implicitly anchored, so use ^ and $ to
+// match the whole string, e.g. regex(^[A-Z]{3}[0-9]{2}$).
+// Expressions are compiled once and cached.
+// Validate panics if val is not a string or the expression is invalid.
+func (v *RegexValidator) Validate(val interface{}, params []string) bool {
+	s, ok := val.(string)
+	if !ok {
+		panic(fmt.Sprintf("regex validator can only validate strings not, %T", val))
+	}
+	if len(params) != 1 {
+		panic("missing parameter for RegexValidator")
+	}
+	re, err := v.compiled(params[0])
+	if err != nil {
+		panic(err.Error())
+	}
+	return re.MatchString(s)
+}
+
+func (v *RegexValidator) compile(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("missing parameter for RegexValidator")
+	}
+	_, err := v.compiled(args[0])
+	return err
+}
+
+func (v *RegexValidator) compiled(expr string) (*regexp.Regexp, error) {
+	v.mu.RLock()
+	re, ok := v.cache[expr]
+	v.mu.RUnlock()
+	if ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex constraint: %v", err)
+	}
+	v.mu.Lock()
+	if v.cache == nil {
+		v.cache = make(map[string]*regexp.Regexp)
+	}
+	v.cache[expr] = re
+	v.mu.Unlock()
+	return re, nil
+}
+
+// Type returns the constraint name (regex).
+func (v *RegexValidator) Type() string {
+	return "regex"
+}
+
+// FailureMsg returns a string with a readable message about the validation failure.
+// The ValidationHandler reports the failure with the pattern itself, using
+// failureMsg, as the validator is shared by every route and model.
+func (v *RegexValidator) FailureMsg() string {
+	return "must match the required pattern."
+}
+
+func (v *RegexValidator) failureMsg(args []string) string {
+	if len(args) != 1 {
+		return v.FailureMsg()
+	}
+	return fmt.Sprintf("must match the pattern %q.", args[0])
+}
+
 func normalizeNumber(i interface{}) (interface{}, bool) {
 	switch reflect.TypeOf(i).Kind() {
 	case reflect.Int,
@@ -1143,5 +1295,6 @@ func getDefaultValidators() []Validator {
 		&EmailValidator{},
 		&NotWhitespaceValidator{},
 		&NotEmptyOrWhitespaceValidator{},
+		&RegexValidator{},
 	}
 }
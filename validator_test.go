@@ -2,6 +2,7 @@ package mango
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 )
 
@@ -2355,6 +2356,115 @@ func TestEmailValidatorPanicsWhenInputNotString(t *testing.T) {
 	v.Validate(32, []string{})
 }
 
+func TestRegexValidatorType(t *testing.T) {
+	want := "regex"
+
+	v := &RegexValidator{}
+	got := v.Type()
+
+	if got != want {
+		t.Errorf("Valid = %q, want %q", got, want)
+	}
+}
+
+func TestRegexValidatorFailureMessage(t *testing.T) {
+	want := "must match the required pattern."
+
+	v := &RegexValidator{}
+	v.Validate("123", []string{"^[a-z]+$"})
+	got := v.FailureMsg()
+
+	if got != want {
+		t.Errorf("Message = %q, want %q", got, want)
+	}
+}
+
+func TestRegexValidationFailureMessageIncludesPattern(t *testing.T) {
+	want := `must match the pattern "^[a-z]+$".`
+
+	h := newValidationHandler()
+	fails, _ := h.IsValid("123", "regex(^[a-z]+$)")
+	if len(fails) != 1 {
+		t.Fatalf("Failure count = %d, want 1", len(fails))
+	}
+	if got := fails[0].Message; got != want {
+		t.Errorf("Message = %q, want %q", got, want)
+	}
+}
+
+func TestRegexValidationFailureMessagesWhenConcurrent(t *testing.T) {
+	h := newValidationHandler()
+	exprs := []string{"^[a-z]+$", "^[0-9]+$"}
+	var wg sync.WaitGroup
+	for _, expr := range exprs {
+		want := fmt.Sprintf("must match the pattern %q.", expr)
+		constraint := "regex(" + expr + ")"
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				fails, _ := h.IsValid("#", constraint)
+				if len(fails) != 1 || fails[0].Message != want {
+					t.Errorf("Failures = %v, want message %q", fails, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRegexValidator(t *testing.T) {
+	var tests = []struct {
+		input   string
+		args    []string
+		want    bool
+		comment string
+	}{
+		{"ABC12", []string{"^[A-Z]{3}[0-9]{2}$"}, true, "Anchored"},
+		{"ABC123", []string{"^[A-Z]{3}[0-9]{2}$"}, false, "AnchoredTooLong"},
+		{"abc12", []string{"^[A-Z]{3}[0-9]{2}$"}, false, "AnchoredWrongCase"},
+		{"xABC12x", []string{"[A-Z]{3}[0-9]{2}"}, true, "Unanchored"},
+		{"red", []string{"^(red|green),?$"}, true, "Alternation"},
+		{"green,", []string{"^(red|green),?$"}, true, "Comma"},
+		{"", []string{"^$"}, true, "Empty"},
+	}
+
+	v := &RegexValidator{}
+
+	for _, test := range tests {
+		if got := v.Validate(test.input, test.args); got != test.want {
+			t.Errorf("Validate (%s): %q regex = %v, want %v", test.comment, test.input, got, test.want)
+		}
+	}
+}
+
+func TestRegexValidatorPanicsWhenInputNotString(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			want := "regex validator can only validate strings not, int"
+			got := r
+			if got != want {
+				t.Errorf("Error message = %q, want %q", got, want)
+			}
+		} else {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	v := &RegexValidator{}
+	v.Validate(32, []string{"^a$"})
+}
+
+func TestRegexValidatorPanicsWhenExpressionInvalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	v := &RegexValidator{}
+	v.Validate("abc", []string{"^[a-z$"})
+}
+
 // ********************
 //
 //  End of validators
@@ -2683,6 +2793,9 @@ func TestParseConstraints(t *testing.T) {
 		{"min(1),range(23.4, 6754),alpha", 3, map[string]string{"alpha": "[]", "min": "[1]", "range": "[23.4 6754]"}},
 		{"alpha,min(1),", 2, map[string]string{"alpha": "[]", "min": "[1]"}},
 		{"alpha,min(1),range(23.4, 6754),", 3, map[string]string{"alpha": "[]", "min": "[1]", "range": "[23.4 6754]"}},
+		{"regex(^[A-Z]{3}$)", 1, map[string]string{"regex": "[^[A-Z]{3}$]"}},
+		{"regex(^(a|b),(c)$),min(1)", 2, map[string]string{"regex": "[^(a|b),(c)$]", "min": "[1]"}},
+		{"alpha, regex(^[)]\\)$) ,min(1)", 3, map[string]string{"alpha": "[]", "regex": "[^[)]\\)$]", "min": "[1]"}},
 	}
 
 	pv := newValidationHandler()
@@ -0,0 +1,88 @@
+package mango
+
+import (
+	"net/http"
+	"strings"
+)
+
+// VersionedHandler is a handler function which is selected by the
+// request media type or version header, allowing several versions of
+// a route to share the same pattern and method.
+type VersionedHandler struct {
+	// Version is matched against the value of the Router VersionHeader.
+	Version string
+	// MediaType is matched against the media types in the request
+	// Accept header, e.g. application/vnd.acme.v2+json.
+	MediaType string
+	// Handler is the handler function for the version. Resource Versions
+	// hold the handler function already wrapped in any Middleware.
+	Handler ContextHandlerFunc
+	// Middleware lists any Middleware attached to the version.
+	Middleware []Middleware
+	raw        ContextHandlerFunc
+	hooks      Middleware
+	name       string
+}
+
+// Version returns a new Group whose routes will only be selected for
+// requests which accept mediaType, or have a Router VersionHeader value
+// of version. Either version or mediaType can be empty.
+// Routes registered through the Group can share a pattern and method
+// with routes of other versions, and with an unversioned route, which
+// is used when the request does not select any version. Requests which
+// select no version of a route without an unversioned route receive a
+// 406 (Not Acceptable) response.
+// Responses to requests for media types with a structured syntax
+// suffix, such as +json, are encoded with the encoder for the suffix
+// if there is no encoder for the media type itself.
+func (r *Router) Version(version, mediaType string) *Group {
+	return r.Group("").Version(version, mediaType)
+}
+
+// Version returns a new nested Group whose routes will only be selected
+// for requests which accept mediaType, or have a Router VersionHeader
+// value of version. See the Router Version method for more details.
+func (g *Group) Version(version, mediaType string) *Group {
+	ng := g.Group("")
+	ng.version = &VersionedHandler{
+		Version:   version,
+		MediaType: strings.ToLower(mediaType),
+	}
+	return ng
+}
+
+// selectVersion returns the handler from vs selected by the request,
+// using the version header if present, otherwise the most preferred
+// media type in the Accept header.
+func (r *Router) selectVersion(req *http.Request, vs []VersionedHandler) (ContextHandlerFunc, bool) {
+	if r.VersionHeader != "" {
+		if ver := req.Header.Get(r.VersionHeader); ver != "" {
+			for _, v := range vs {
+				if v.Version != "" && v.Version == ver {
+					return v.Handler, true
+				}
+			}
+			return nil, false
+		}
+	}
+	for _, mt := range acceptableMediaTypes(req.Header.Get("Accept")) {
+		mt = strings.ToLower(strings.SplitN(mt, ";", 2)[0])
+		for _, v := range vs {
+			if v.MediaType != "" && v.MediaType == mt {
+				return v.Handler, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// suffixMediaType returns the media type corresponding to the structured
+// syntax suffix of mt, e.g. application/json for application/vnd.acme+json,
+// or an empty string if mt has no suffix.
+func suffixMediaType(mt string) string {
+	i := strings.LastIndexByte(mt, '+')
+	if i < 0 || strings.IndexByte(mt, '/') > i {
+		return ""
+	}
+	return "application/" + mt[i+1:]
+}
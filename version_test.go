@@ -0,0 +1,205 @@
+package mango
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func versionRouter() *Router {
+	rtr := NewRouter()
+	rtr.VersionHeader = "X-Api-Version"
+	rtr.Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "default"})
+	})
+	rtr.Version("1", "application/vnd.acme.v1+json").Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "1"})
+	})
+	rtr.Version("2", "application/vnd.acme.v2+json").Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "2"})
+	})
+	return rtr
+}
+
+func TestVersionedRouteSelection(t *testing.T) {
+	tests := []struct {
+		accept  string
+		version string
+		want    string
+	}{
+		{"", "", `200|application/json|{"version":"default"}`},
+		{"application/json", "", `200|application/json|{"version":"default"}`},
+		{"application/vnd.acme.v1+json", "", `200|application/vnd.acme.v1+json|{"version":"1"}`},
+		{"application/vnd.acme.v2+json", "", `200|application/vnd.acme.v2+json|{"version":"2"}`},
+		{"application/vnd.acme.v1+json;q=0.5, application/vnd.acme.v2+json", "", `200|application/vnd.acme.v2+json|{"version":"2"}`},
+		{"text/html, application/vnd.acme.v1+json;q=0.8", "", `200|application/vnd.acme.v1+json|{"version":"1"}`},
+		{"", "2", `200|application/json|{"version":"2"}`},
+		{"application/vnd.acme.v1+json", "2", `200|application/vnd.acme.v1+json|{"version":"2"}`},
+	}
+	rtr := versionRouter()
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/mango", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		if test.version != "" {
+			req.Header.Set("X-Api-Version", test.version)
+		}
+		rtr.ServeHTTP(w, req)
+
+		got := fmt.Sprintf("%d|%s|%s", w.Code, w.Header().Get("Content-Type"), strings.TrimSpace(w.Body.String()))
+		if got != test.want {
+			t.Errorf("Accept %q, version %q got %q, want %q", test.accept, test.version, got, test.want)
+		}
+	}
+}
+
+func TestVersionedRouteReturns406WhenNoVersionSelected(t *testing.T) {
+	want := 406
+	rtr := NewRouter()
+	rtr.Version("2", "application/vnd.acme.v2+json").Get("/mango", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	req.Header.Set("Accept", "application/vnd.acme.v1+json")
+	rtr.ServeHTTP(w, req)
+
+	got := w.Code
+	if got != want {
+		t.Errorf("Status got %d, want %d", got, want)
+	}
+}
+
+func TestVersionedRouteReturns405WithVersionedMethodsInAllow(t *testing.T) {
+	want := "405|GET"
+	rtr := NewRouter()
+	rtr.Version("2", "application/vnd.acme.v2+json").Get("/mango", testFunc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mango", nil)
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, w.Header().Get("Allow"))
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestVersionedRoutePanicsWhenVersionDuplicated(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	rtr := NewRouter()
+	rtr.Version("2", "application/vnd.acme.v2+json").Get("/mango", testFunc)
+	rtr.Version("2", "application/vnd.acme.v2+json").Get("/mango", testFunc)
+}
+
+func TestVersionedRoutePanicsWhenMediaTypeDuplicated(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	rtr := NewRouter()
+	rtr.Version("", "application/vnd.acme.v2+json").Get("/mango", testFunc)
+	rtr.Version("", "application/vnd.acme.v2+json").Get("/mango", testFunc)
+}
+
+func TestVersionedRoutesWithOnlyMediaTypes(t *testing.T) {
+	want := `200|{"version":"2"}`
+	rtr := NewRouter()
+	rtr.Version("", "application/vnd.acme.v1+json").Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "1"})
+	})
+	rtr.Version("", "application/vnd.acme.v2+json").Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "2"})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	req.Header.Set("Accept", "application/vnd.acme.v2+json")
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, strings.TrimSpace(w.Body.String()))
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestVersionedRoutesWithOnlyVersions(t *testing.T) {
+	want := `200|{"version":"2"}`
+	rtr := NewRouter()
+	rtr.VersionHeader = "X-Api-Version"
+	rtr.Version("1", "").Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "1"})
+	})
+	rtr.Version("2", "").Get("/mango", func(c *Context) {
+		c.RespondWith(map[string]string{"version": "2"})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/mango", nil)
+	req.Header.Set("X-Api-Version", "2")
+	rtr.ServeHTTP(w, req)
+
+	got := fmt.Sprintf("%d|%s", w.Code, strings.TrimSpace(w.Body.String()))
+	if got != want {
+		t.Errorf("Response got %q, want %q", got, want)
+	}
+}
+
+func TestVersionedRouteBindsStructuredSuffixContent(t *testing.T) {
+	want := "Jeff"
+	rtr := NewRouter()
+	got := ""
+	rtr.Version("2", "application/vnd.acme.v2+json").Post("/mango", func(c *Context) {
+		m := struct{ Name string }{}
+		c.Bind(&m)
+		got = m.Name
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mango", strings.NewReader(`{"Name":"Jeff"}`))
+	req.Header.Set("Accept", "application/vnd.acme.v2+json")
+	req.Header.Set("Content-Type", "application/vnd.acme.v2+json")
+	rtr.ServeHTTP(w, req)
+
+	if got != want {
+		t.Errorf("Name got %q, want %q", got, want)
+	}
+}
+
+func TestRouterRoutesIncludesVersions(t *testing.T) {
+	want := "|/mango,GET,,|/mango,GET,1,application/vnd.acme.v1+json|/mango,GET,2,application/vnd.acme.v2+json"
+	rtr := versionRouter()
+	got := ""
+	for _, ri := range rtr.Routes() {
+		got += fmt.Sprintf("|%s,%s,%s,%s", ri.Pattern, ri.Method, ri.Version, ri.MediaType)
+	}
+	if got != want {
+		t.Errorf("Routes got %q, want %q", got, want)
+	}
+}
+
+func TestSuffixMediaType(t *testing.T) {
+	tests := []struct {
+		mt   string
+		want string
+	}{
+		{"application/vnd.acme.v2+json", "application/json"},
+		{"application/problem+xml", "application/xml"},
+		{"application/json", ""},
+		{"text/plain", ""},
+	}
+	for _, test := range tests {
+		got := suffixMediaType(test.mt)
+		if got != test.want {
+			t.Errorf("suffixMediaType(%q) = %q, want %q", test.mt, got, test.want)
+		}
+	}
+}